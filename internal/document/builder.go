@@ -0,0 +1,102 @@
+package document
+
+import "strings"
+
+// lineBuilder accumulates the lines of a rendered document, separating
+// paragraphs with a single blank line and recording where anchors land.
+type lineBuilder struct {
+	lines []string
+	kinds map[int]LineKind
+	spans map[int][]Span
+
+	// Anchors are resolved to the next line emitted after they were added.
+	anchors        map[string]int
+	pendingAnchors []string
+
+	pendingBlank bool
+}
+
+func newLineBuilder() lineBuilder {
+	return lineBuilder{
+		kinds:   make(map[int]LineKind),
+		spans:   make(map[int][]Span),
+		anchors: make(map[string]int),
+	}
+}
+
+func (b *lineBuilder) emit(line string, kind LineKind, spans ...Span) {
+	if b.pendingBlank && len(b.lines) > 0 {
+		b.lines = append(b.lines, "")
+	}
+	b.pendingBlank = false
+	for _, anchor := range b.pendingAnchors {
+		b.anchors[anchor] = len(b.lines)
+	}
+	b.pendingAnchors = nil
+	if kind != LineText {
+		b.kinds[len(b.lines)] = kind
+	}
+	if len(spans) > 0 {
+		b.spans[len(b.lines)] = spans
+	}
+	b.lines = append(b.lines, line)
+}
+
+// nextLine is the index the next emitted line will get.
+func (b *lineBuilder) nextLine() int {
+	if b.pendingBlank && len(b.lines) > 0 {
+		return len(b.lines) + 1
+	}
+	return len(b.lines)
+}
+
+func (b *lineBuilder) paragraphBreak() {
+	b.pendingBlank = true
+}
+
+func (b *lineBuilder) anchor(name string) {
+	b.pendingAnchors = append(b.pendingAnchors, name)
+}
+
+// finish resolves the anchors left at the very end of the content.
+func (b *lineBuilder) finish() {
+	for _, anchor := range b.pendingAnchors {
+		b.anchors[anchor] = len(b.lines)
+	}
+	b.pendingAnchors = nil
+}
+
+func (b *lineBuilder) document() *Document {
+	return &Document{
+		Lines: b.lines,
+		Kinds: b.kinds,
+		Spans: b.spans,
+	}
+}
+
+// appendText adds text to sb collapsing whitespace runs into single spaces, the
+// way browsers lay out inline content. Whitespace is never doubled nor added at
+// the start, so offsets into sb stay valid once the paragraph is trimmed.
+func appendText(sb *strings.Builder, data string) {
+	words := strings.Fields(data)
+	if (len(words) == 0 && data != "") || startsWithSpace(data) {
+		if sb.Len() > 0 && !endsWithSpace(sb.String()) {
+			sb.WriteString(" ")
+		}
+	}
+	if len(words) == 0 {
+		return
+	}
+	sb.WriteString(strings.Join(words, " "))
+	if endsWithSpace(data) {
+		sb.WriteString(" ")
+	}
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s[:1], " \t\r\n\f") == ""
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s[len(s)-1:], " \t\r\n\f") == ""
+}
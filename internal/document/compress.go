@@ -0,0 +1,127 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	gzipMagic       = Magic{Bytes: []byte{0x1f, 0x8b}}
+	bzip2Magic      = Magic{Bytes: []byte("BZh")}
+	bzip2BlockMagic = Magic{Offset: 4, Bytes: []byte("1AY&SY")} // First block, after the block size
+	zipMagic        = Magic{Bytes: []byte("PK\x03\x04")}
+)
+
+// isBzip2 reports whether data starts with a full bzip2 header: "BZh", the
+// block size from 1 to 9 and the signature of the first block. "BZh" alone
+// is too likely at the start of plain text.
+func isBzip2(data []byte) bool {
+	return bzip2Magic.matches(data) && len(data) > 3 && '1' <= data[3] && data[3] <= '9' && bzip2BlockMagic.matches(data)
+}
+
+// decompress unwraps one layer of gzip, bzip2 or zip compression. The
+// returned source is named after the logical document, e.g. "book.txt" for
+// "book.txt.gz", so that progress is shared with the uncompressed file.
+// Zip archives are only unwrapped when named *.zip, since EPUB and other
+// formats are zip files too. Data that only looks compressed, failing to
+// decompress, is left as it is.
+func decompress(src Source) (Source, bool, error) {
+	switch {
+	case gzipMagic.matches(src.Data):
+		zr, err := gzip.NewReader(bytes.NewReader(src.Data))
+		if err != nil {
+			return src, false, nil
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return src, false, nil
+		}
+		name := trimExtension(src.Name, ".gz", ".gzip")
+		if name == src.Name && zr.Name != "" {
+			name = filepath.Join(filepath.Dir(src.Name), path.Base(zr.Name))
+		}
+		return Source{Name: name, Data: data}, true, nil
+
+	case isBzip2(src.Data):
+		data, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(src.Data)))
+		if err != nil {
+			return src, false, nil
+		}
+		return Source{Name: trimExtension(src.Name, ".bz2", ".bzip2"), Data: data}, true, nil
+
+	case zipMagic.matches(src.Data) && strings.HasSuffix(strings.ToLower(src.Name), ".zip"):
+		return unzipSingle(src)
+	}
+	return src, false, nil
+}
+
+// unzipSingle extracts the only document of a zip archive. Archives holding
+// several files are accepted as long as exactly one of them is in a format
+// with a registered loader, otherwise it is an error.
+func unzipSingle(src Source) (Source, bool, error) {
+	z, err := zip.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		return src, false, nil // Not a zip archive after all
+	}
+
+	var files, known []*zip.File
+	for _, f := range z.File {
+		base := path.Base(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		files = append(files, f)
+		if hasRegisteredExtension(base) {
+			known = append(known, f)
+		}
+	}
+	if len(files) != 1 {
+		files = known
+	}
+	switch {
+	case len(files) == 0:
+		return src, false, fmt.Errorf("no document found in zip archive")
+	case len(files) > 1:
+		return src, false, fmt.Errorf("zip archive contains %d documents, expected one", len(files))
+	}
+
+	rc, err := files[0].Open()
+	if err != nil {
+		return src, false, fmt.Errorf("error opening %s in zip archive: %v", files[0].Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return src, false, fmt.Errorf("error reading %s in zip archive: %v", files[0].Name, err)
+	}
+	name := filepath.Join(filepath.Dir(src.Name), path.Base(files[0].Name))
+	return Source{Name: name, Data: data}, true, nil
+}
+
+func trimExtension(name string, extensions ...string) string {
+	for _, ext := range extensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+func hasRegisteredExtension(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range formats {
+		for _, ext := range f.extensions {
+			if strings.HasSuffix(name, ext) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipFiles builds a zip archive holding the given name and content pairs, in
+// order.
+func zipFiles(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		method := zip.Deflate
+		if files[i] == "mimetype" {
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: files[i], Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testBzip2 is "one\ntwo\n" compressed with bzip2, which the standard library
+// can only decompress.
+const testBzip2 = "QlpoOTFBWSZTWacUK3cAAALBgAAQAgGEgCAAIYAMAjj1G4u5IpwoSFOKFbuA"
+
+func TestDecompress(t *testing.T) {
+	bz, err := base64.StdEncoding.DecodeString(testBzip2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		data  []byte
+		id    string
+		lines []string
+	}{
+		{"book.txt.gz", gzipped(t, "one\ntwo\n"), "book.txt", []string{"one", "two"}},
+		{"notes.md.gz", gzipped(t, "# Title\n\nText"), "notes.md", []string{"Title", "", "Text"}},
+		{"book.txt.bz2", bz, "book.txt", []string{"one", "two"}},
+		{"book.zip", zipFiles(t, "dir/book.txt", "one\ntwo\n"), "book.txt", []string{"one", "two"}},
+		{"books/book.zip", zipFiles(t, "__MACOSX/._book.md", "junk", "book.md", "# One"), "books/book.md", []string{"One"}},
+		{"book.zip", zipFiles(t, "README", "about", "cover.jpg", "image", "book.txt", "one"), "book.txt", []string{"one"}},
+		{"book.txt.gz.gz", gzipped(t, string(gzipped(t, "one"))), "book.txt", []string{"one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := load(t, tt.name, tt.data)
+			if doc.ID != tt.id {
+				t.Errorf("ID = %q, want %q", doc.ID, tt.id)
+			}
+			if !slices.Equal(doc.Lines, tt.lines) {
+				t.Errorf("Lines = %q, want %q", doc.Lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestDecompressZipErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"hidden.zip", zipFiles(t, ".hidden", "x"), "no document found"},
+		{"two.zip", zipFiles(t, "a.txt", "a", "b.txt", "b"), "contains 2 documents"},
+	}
+	for _, tt := range tests {
+		_, err := Load(Source{Name: tt.name, Data: tt.data})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDecompressFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"notes.txt", "BZh is how this line starts", "BZh is how this line starts"},
+		{"notes.txt", "BZh91AY&SY but the rest is not bzip2", "BZh91AY&SY but the rest is not bzip2"},
+		{"notes.txt", "\x1f\x8b not really gzip", "\x1f‹ not really gzip"}, // Read as windows-1252
+		{"notes.zip", "PK\x03\x04 not really zip", "PK\x03\x04 not really zip"},
+	}
+	for _, tt := range tests {
+		doc := load(t, tt.name, []byte(tt.data))
+		if !slices.Equal(doc.Lines, []string{tt.want}) {
+			t.Errorf("Load(%q) = %q, want %q", tt.data, doc.Lines, tt.want)
+		}
+	}
+}
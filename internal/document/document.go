@@ -0,0 +1,265 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"txtreader/internal/utils"
+)
+
+// Chapter marks the line where a section of the document starts. Level is
+// the nesting depth in the table of contents, 0 for top level entries.
+type Chapter struct {
+	Title string
+	Line  int
+	Level int
+}
+
+// Metadata holds the descriptive information a format may carry.
+type Metadata struct {
+	Title     string
+	Author    string
+	Language  string
+	Publisher string
+}
+
+// LineKind tells the reader how a line should be styled.
+type LineKind int
+
+const (
+	LineText LineKind = iota
+	LineHeading
+	LineQuote
+	LineCode
+)
+
+// SpanStyle is a set of inline style flags.
+type SpanStyle int
+
+const (
+	SpanEmphasis SpanStyle = 1 << iota
+	SpanStrong
+	SpanCode
+)
+
+// Span styles the bytes [Start, End) of a line.
+type Span struct {
+	Start int
+	End   int
+	Style SpanStyle
+}
+
+// Cue is a subtitle shown from Start to End, the text of which starts at Line.
+type Cue struct {
+	Line  int
+	Start time.Duration
+	End   time.Duration
+}
+
+// Document is the result of loading a file: the lines shown by the reader plus
+// whatever structure the format provides.
+type Document struct {
+	// ID identifies the logical document the progress is stored under. For
+	// files it is the path without compression extensions.
+	ID       string
+	Lines    []string
+	Kinds    map[int]LineKind // Lines missing from the map are LineText
+	Spans    map[int][]Span   // Inline styles, by line
+	Chapters []Chapter
+	Cues     []Cue // Subtitle timings, sorted by line
+	Metadata Metadata
+
+	release func() error // Unmaps the file the lines may point into
+}
+
+// Close releases the memory a large file was mapped to. The lines of the
+// document must not be used once it is closed.
+func (d *Document) Close() error {
+	if d.release == nil {
+		return nil
+	}
+	release := d.release
+	d.release = nil
+	return release()
+}
+
+// Source is the raw content handed to a Loader. Name is only used for
+// extension matching and error messages. Encoding, when set, forces the
+// character encoding of text based formats instead of detecting it.
+type Source struct {
+	Name     string
+	Data     []byte
+	Encoding string
+}
+
+// Options tune how Open reads a file.
+type Options struct {
+	Encoding string
+}
+
+// Loader turns a Source into a Document.
+type Loader interface {
+	Load(src Source) (*Document, error)
+}
+
+// LoaderFunc adapts a plain function to the Loader interface.
+type LoaderFunc func(src Source) (*Document, error)
+
+func (f LoaderFunc) Load(src Source) (*Document, error) {
+	return f(src)
+}
+
+// Magic is a byte signature expected at a given offset of the content.
+type Magic struct {
+	Offset int
+	Bytes  []byte
+}
+
+func (mg Magic) matches(data []byte) bool {
+	end := mg.Offset + len(mg.Bytes)
+	return end <= len(data) && bytes.Equal(data[mg.Offset:end], mg.Bytes)
+}
+
+type format struct {
+	name       string
+	extensions []string
+	magic      []Magic
+	loader     Loader
+}
+
+var formats []format
+
+// Register makes a loader available for files with any of the given
+// extensions (including the dot, e.g. ".epub") or starting with any of the
+// given signatures.
+func Register(name string, loader Loader, extensions []string, magic ...Magic) {
+	formats = append(formats, format{
+		name:       name,
+		extensions: extensions,
+		magic:      magic,
+		loader:     loader,
+	})
+}
+
+// Detect picks the loader for src. Signatures win over extensions because the
+// content is more reliable than the name; anything unknown is plain text.
+func Detect(src Source) (string, Loader) {
+	for _, f := range formats {
+		for _, mg := range f.magic {
+			if mg.matches(src.Data) {
+				return f.name, f.loader
+			}
+		}
+	}
+
+	name := strings.ToLower(src.Name)
+	for _, f := range formats {
+		for _, ext := range f.extensions {
+			if strings.HasSuffix(name, ext) {
+				return f.name, f.loader
+			}
+		}
+	}
+
+	return plainTextFormat, plainTextLoader
+}
+
+// Load decompresses src if needed, detects its format and loads it.
+func Load(src Source) (*Document, error) {
+	for {
+		inner, ok, err := decompress(src)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		inner.Encoding = src.Encoding
+		src = inner
+	}
+
+	_, loader := Detect(src)
+	doc, err := loader.Load(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reader always needs a line to stand on, even for empty files.
+	if len(doc.Lines) == 0 {
+		doc.Lines = []string{""}
+	}
+	if doc.ID == "" {
+		doc.ID = src.Name
+	}
+	return doc, nil
+}
+
+// StdinPath is the path that makes Open read from standard input.
+const StdinPath = "-"
+
+// Open reads the file at path and loads it with the matching loader.
+func Open(path string, opts Options) (*Document, error) {
+	if path == StdinPath {
+		return openStdin(opts)
+	}
+	data, release, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	doc, err := Load(Source{Name: path, Data: data, Encoding: opts.Encoding})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	doc.release = release
+	return doc, nil
+}
+
+// mapThreshold is the size from which files are mapped into memory instead
+// of read. Plain text is then split into lines pointing into the mapping, so
+// even huge files open without being copied.
+const mapThreshold = 16 << 20
+
+// writeSettleTime is how long a file must have gone unmodified to be mapped.
+// The lines point into the mapping for the whole session, and touching pages
+// a truncated file no longer has kills the reader with SIGBUS, so logs still
+// being written are read instead. Files truncated or rotated in place after
+// they were mapped remain a risk.
+const writeSettleTime = time.Minute
+
+// readFile returns the contents of a file and a function releasing them.
+func readFile(path string) ([]byte, func() error, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() < mapThreshold || !info.Mode().IsRegular() || time.Since(info.ModTime()) < writeSettleTime {
+		data, err := os.ReadFile(path)
+		return data, func() error { return nil }, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close() // The mapping outlives the descriptor
+	return mapFile(f, info.Size())
+}
+
+// openStdin loads a document piped through standard input. Since there is no
+// path to identify it, the progress is keyed by a hash of the content.
+func openStdin(opts Options) (*Document, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("error reading standard input: %v", err)
+	}
+	doc, err := Load(Source{Data: data, Encoding: opts.Encoding})
+	if err != nil {
+		return nil, err
+	}
+	doc.ID = "stdin:" + utils.HashContent(data)
+	return doc, nil
+}
@@ -0,0 +1,113 @@
+package document
+
+import (
+	"slices"
+	"testing"
+)
+
+func load(t *testing.T, name string, data []byte) *Document {
+	t.Helper()
+	doc, err := Load(Source{Name: name, Data: data})
+	if err != nil {
+		t.Fatalf("Load(%q): %v", name, err)
+	}
+	return doc
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{""}},
+		{"one", []string{"one"}},
+		{"one\n", []string{"one"}},
+		{"one\ntwo", []string{"one", "two"}},
+		{"one\r\ntwo\r\n", []string{"one", "two"}},
+		{"one\n\ntwo\n\n", []string{"one", "", "two", ""}},
+	}
+	for _, tt := range tests {
+		if got := splitLines(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("splitLines(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"notes.txt", "text", plainTextFormat},
+		{"README", "text", plainTextFormat},
+		{"README.md", "# Title", markdownFormat},
+		{"page.HTML", "<p>text</p>", htmlFormat},
+		{"book.fb2", "<FictionBook/>", fb2Format},
+		{"movie.srt", "1\n00:00:01,000 --> 00:00:02,000\nHi", subtitlesFormat},
+		{"captions", "WEBVTT\n\n00:01.000 --> 00:02.000\nHi", subtitlesFormat},
+		// The content wins over a misleading name.
+		{"captions.txt", "WEBVTT\n\n00:01.000 --> 00:02.000\nHi", subtitlesFormat},
+	}
+	for _, tt := range tests {
+		if got, _ := Detect(Source{Name: tt.name, Data: []byte(tt.data)}); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	registered := formats
+	defer func() { formats = registered }()
+
+	loader := LoaderFunc(func(src Source) (*Document, error) {
+		return &Document{Lines: []string{"custom " + src.Name}}, nil
+	})
+	Register("custom", loader, []string{".cst"}, Magic{Offset: 2, Bytes: []byte("CST")})
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"file.cst", "anything", "custom"},
+		{"FILE.CST", "anything", "custom"},
+		{"file.txt", "..CST", "custom"},
+		{"file.md", "..CST", "custom"},
+		{"file.txt", "CST", plainTextFormat},
+		{"file.cst.bak", "text", plainTextFormat},
+	}
+	for _, tt := range tests {
+		if got, _ := Detect(Source{Name: tt.name, Data: []byte(tt.data)}); got != tt.want {
+			t.Errorf("Detect(%q, %q) = %q, want %q", tt.name, tt.data, got, tt.want)
+		}
+	}
+
+	doc := load(t, "book.cst", nil)
+	if !slices.Equal(doc.Lines, []string{"custom book.cst"}) || doc.ID != "book.cst" {
+		t.Errorf("Load = %q with ID %q, want the custom loader's lines", doc.Lines, doc.ID)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		lines []string
+	}{
+		{"empty.txt", nil, []string{""}},
+		{"plain.txt", []byte("one\ntwo\n"), []string{"one", "two"}},
+		{"windows.txt", []byte("one\r\ntwo"), []string{"one", "two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := load(t, tt.name, tt.data)
+			if doc.ID != tt.name {
+				t.Errorf("ID = %q, want %q", doc.ID, tt.name)
+			}
+			if !slices.Equal(doc.Lines, tt.lines) {
+				t.Errorf("Lines = %q, want %q", doc.Lines, tt.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const docxFormat = "docx"
+
+func init() {
+	// DOCX files are plain zip archives, so they are only told apart by name.
+	Register(docxFormat, LoaderFunc(loadDOCX), []string{".docx"})
+}
+
+// headingStyleRe matches the names Word gives its built-in heading styles.
+// Style ids are translated ("Ttulo1" in Spanish), the names are not.
+var headingStyleRe = regexp.MustCompile(`(?i)^heading\s*(\d)$`)
+
+type docxStyles struct {
+	Styles []struct {
+		ID   string `xml:"styleId,attr"`
+		Name struct {
+			Val string `xml:"val,attr"`
+		} `xml:"name"`
+		OutlineLevel *struct {
+			Val int `xml:"val,attr"`
+		} `xml:"pPr>outlineLvl"`
+	} `xml:"style"`
+}
+
+// headingLevels maps the ids of the paragraph styles used for headings to
+// their level, starting at 1.
+func (s docxStyles) headingLevels() map[string]int {
+	levels := make(map[string]int)
+	for _, style := range s.Styles {
+		name := strings.TrimSpace(style.Name.Val)
+		switch m := headingStyleRe.FindStringSubmatch(name); {
+		case m != nil:
+			levels[style.ID], _ = strconv.Atoi(m[1])
+		case strings.EqualFold(name, "title"):
+			levels[style.ID] = 1
+		case style.OutlineLevel != nil && style.OutlineLevel.Val < 9:
+			levels[style.ID] = style.OutlineLevel.Val + 1
+		}
+	}
+	return levels
+}
+
+// officeCoreProperties is the docProps/core.xml part of OOXML packages.
+type officeCoreProperties struct {
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator"`
+	Language string `xml:"language"`
+}
+
+// docxRenderer walks word/document.xml. Paragraphs become lines, bold and
+// italic runs spans, and paragraphs with a heading style chapters.
+type docxRenderer struct {
+	officeRenderer
+
+	headingLevels map[string]int
+	footnotes     map[string]string
+	endnotes      map[string]string
+
+	level  int // Heading level of the current paragraph, 0 for body text
+	bold   bool
+	italic bool
+	inText bool
+}
+
+func loadDOCX(src Source) (*Document, error) {
+	z, err := openZip(src, "DOCX")
+	if err != nil {
+		return nil, err
+	}
+	body, err := readZipEntry(z, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, fmt.Errorf("no word/document.xml found in DOCX")
+	}
+
+	r := &docxRenderer{officeRenderer: newOfficeRenderer()}
+	if data, err := readZipEntry(z, "word/styles.xml"); err == nil && data != nil {
+		var styles docxStyles
+		if xml.Unmarshal(data, &styles) == nil {
+			r.headingLevels = styles.headingLevels()
+		}
+	}
+	if data, err := readZipEntry(z, "word/footnotes.xml"); err == nil && data != nil {
+		r.footnotes = docxNotes(data, "footnote")
+	}
+	if data, err := readZipEntry(z, "word/endnotes.xml"); err == nil && data != nil {
+		r.endnotes = docxNotes(data, "endnote")
+	}
+	if err := r.render(body); err != nil {
+		return nil, fmt.Errorf("error parsing DOCX: %v", err)
+	}
+	r.finishNotes()
+
+	doc := r.document()
+	if data, err := readZipEntry(z, "docProps/core.xml"); err == nil && data != nil {
+		var core officeCoreProperties
+		if xml.Unmarshal(data, &core) == nil {
+			doc.Metadata = Metadata{
+				Title:    strings.TrimSpace(core.Title),
+				Author:   strings.TrimSpace(core.Creator),
+				Language: strings.TrimSpace(core.Language),
+			}
+		}
+	}
+	return doc, nil
+}
+
+func (r *docxRenderer) render(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "txbxContent", "Fallback", "del":
+				// Text boxes nest paragraphs inside runs, and the fallback of
+				// alternate content repeats them. Deleted revisions are gone.
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+			default:
+				r.start(t)
+			}
+		case xml.EndElement:
+			r.end(t.Name.Local)
+		case xml.CharData:
+			if r.inText {
+				r.write(string(t), r.runStyle())
+			}
+		}
+	}
+}
+
+func (r *docxRenderer) start(t xml.StartElement) {
+	switch t.Name.Local {
+	case "p":
+		r.level = 0
+		r.inline.Reset()
+		r.spans = nil
+	case "pStyle":
+		if level, ok := r.headingLevels[xmlAttr(t, "val")]; ok {
+			r.level = level
+		} else if m := headingStyleRe.FindStringSubmatch(xmlAttr(t, "val")); m != nil {
+			// Documents without styles.xml use the built-in ids.
+			r.level, _ = strconv.Atoi(m[1])
+		}
+	case "outlineLvl":
+		if level, err := strconv.Atoi(xmlAttr(t, "val")); err == nil && level < 9 {
+			r.level = level + 1
+		}
+	case "numPr":
+		r.prefix = listBullet(0)
+	case "ilvl":
+		depth, _ := strconv.Atoi(xmlAttr(t, "val"))
+		r.prefix = listBullet(depth)
+	case "r":
+		r.bold, r.italic = false, false
+	case "b":
+		r.bold = xmlBool(xmlAttr(t, "val"))
+	case "i":
+		r.italic = xmlBool(xmlAttr(t, "val"))
+	case "t":
+		r.inText = true
+	case "tab":
+		r.write(" ", 0)
+	case "br", "cr":
+		if xmlAttr(t, "type") != "page" {
+			r.lineBreak(r.level)
+		}
+	case "footnoteReference":
+		r.noteRef(r.footnotes[xmlAttr(t, "id")])
+	case "endnoteReference":
+		r.noteRef(r.endnotes[xmlAttr(t, "id")])
+	}
+}
+
+func (r *docxRenderer) end(name string) {
+	switch name {
+	case "t":
+		r.inText = false
+	case "p":
+		r.flush(r.level)
+	}
+}
+
+func (r *docxRenderer) runStyle() SpanStyle {
+	var style SpanStyle
+	if r.bold {
+		style |= SpanStrong
+	}
+	if r.italic {
+		style |= SpanEmphasis
+	}
+	return style
+}
+
+// docxNotes reads the text of the notes in word/footnotes.xml or
+// word/endnotes.xml by id. element is the name of the note elements.
+func docxNotes(data []byte, element string) map[string]string {
+	notes := make(map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var id string
+	var note strings.Builder
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return notes
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case element:
+				id = xmlAttr(t, "id")
+				note.Reset()
+			case "t":
+				inText = true
+			case "tab", "p":
+				appendText(&note, " ")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case element:
+				notes[id] = strings.TrimSpace(note.String())
+			}
+		case xml.CharData:
+			if inText {
+				appendText(&note, string(t))
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// decodeText converts text in an unknown encoding to UTF-8. A non-empty
+// forced name (e.g. "latin1", "windows-1251") skips detection. Otherwise a
+// BOM decides, then UTF-16 is recognized by its zero bytes, valid UTF-8 is
+// kept as is and anything else is assumed to be a legacy single-byte encoding.
+// UTF-16 goes first since ASCII text in it is valid UTF-8 too, NULs included.
+func decodeText(data []byte, forced string) ([]byte, error) {
+	if forced != "" {
+		enc, err := htmlindex.Get(forced)
+		if err != nil {
+			return nil, fmt.Errorf("unknown encoding %q", forced)
+		}
+		return decodeWith(enc, data)
+	}
+
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):], nil
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return decodeWith(unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), data)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return decodeWith(unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), data)
+	}
+
+	if enc := detectUTF16(data); enc != nil {
+		return decodeWith(enc, data)
+	}
+	if utf8.Valid(data) {
+		return data, nil
+	}
+	return decodeWith(detectSingleByte(data), data)
+}
+
+// decodeHTML converts an HTML page to UTF-8, honouring its <meta charset> and
+// falling back to decodeText when the page does not declare an encoding.
+func decodeHTML(data []byte, forced string) ([]byte, error) {
+	if forced == "" {
+		if enc, name, certain := charset.DetermineEncoding(data, ""); certain || name == "utf-8" {
+			return decodeWith(enc, data)
+		}
+	}
+	return decodeText(data, forced)
+}
+
+func decodeWith(enc encoding.Encoding, data []byte) ([]byte, error) {
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding text: %v", err)
+	}
+	return decoded, nil
+}
+
+// detectUTF16 recognizes BOM-less UTF-16 by the zero high bytes of ASCII
+// characters, which land on odd offsets in little endian and even in big.
+func detectUTF16(data []byte) encoding.Encoding {
+	sample := data[:min(len(data), 4096)]
+	if len(sample) < 4 {
+		return nil
+	}
+	var even, odd int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	half := len(sample) / 2
+	switch {
+	case odd > half*3/10 && even*20 < half:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case even > half*3/10 && odd*20 < half:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	}
+	return nil
+}
+
+// detectSingleByte tells Cyrillic windows-1251 from Western windows-1252
+// (a superset of Latin-1). Spanish or French text has its non-ASCII letters
+// scattered among ASCII ones, as in "acción", while Cyrillic words are made
+// entirely of high bytes.
+func detectSingleByte(data []byte) encoding.Encoding {
+	var high, inRuns int
+	run := 0
+	countRun := func() {
+		if run >= 3 {
+			inRuns += run
+		}
+		run = 0
+	}
+	for _, b := range data {
+		if b >= 0xc0 {
+			high++
+			run++
+			continue
+		}
+		countRun()
+	}
+	countRun()
+
+	if high > 0 && inRuns*2 > high {
+		return charmap.Windows1251
+	}
+	return charmap.Windows1252
+}
@@ -0,0 +1,56 @@
+package document
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	data, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encoding %q: %v", s, err)
+	}
+	return data
+}
+
+func TestDecodeText(t *testing.T) {
+	const spanish = "La acción empieza aquí, señor."
+	const russian = "Война и мир, роман Толстого."
+	tests := []struct {
+		name   string
+		data   []byte
+		forced string
+		want   string
+	}{
+		{"utf-8", []byte(spanish), "", spanish},
+		{"utf-8 with bom", append([]byte("\xef\xbb\xbf"), spanish...), "", spanish},
+		{"utf-16le with bom", encode(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), spanish), "", spanish},
+		{"utf-16be with bom", encode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), spanish), "", spanish},
+		{"utf-16le", encode(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), spanish), "", spanish},
+		{"utf-16be", encode(t, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), spanish), "", spanish},
+		{"ascii utf-16le", encode(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "Hello world"), "", "Hello world"},
+		{"ascii utf-16be", encode(t, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), "Hello world"), "", "Hello world"},
+		{"windows-1252", encode(t, charmap.Windows1252, spanish), "", spanish},
+		{"windows-1251", encode(t, charmap.Windows1251, russian), "", russian},
+		{"forced", encode(t, charmap.KOI8R, russian), "koi8-r", russian},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeText(tt.data, tt.forced)
+			if err != nil {
+				t.Fatalf("decodeText: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decodeText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := decodeText([]byte("text"), "no-such-encoding"); err == nil {
+		t.Error("decodeText with an unknown encoding succeeded")
+	}
+}
@@ -0,0 +1,274 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/net/html"
+)
+
+const epubFormat = "epub"
+
+const ncxMediaType = "application/x-dtbncx+xml"
+
+func init() {
+	// EPUB archives must store an uncompressed "mimetype" entry first, so the
+	// media type always sits right after the 30 byte zip local header.
+	Register(epubFormat, LoaderFunc(loadEPUB), []string{".epub"},
+		Magic{Offset: 30, Bytes: []byte("mimetypeapplication/epub+zip")})
+}
+
+// tocEntry is a table of contents link. Target is relative to the package
+// document and may carry a #fragment.
+type tocEntry struct {
+	title  string
+	target string
+	level  int
+}
+
+func loadEPUB(src Source) (*Document, error) {
+	rd, err := epub.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		return nil, fmt.Errorf("error opening EPUB: %v", err)
+	}
+
+	if len(rd.Rootfiles) == 0 {
+		return nil, fmt.Errorf("no rootfiles found in EPUB")
+	}
+	book := rd.Rootfiles[0]
+
+	r := newHTMLRenderer()
+	var navTOC []tocEntry
+
+	for _, itemref := range book.Spine.Itemrefs {
+		if itemref.Item == nil {
+			continue
+		}
+		doc, err := parseEPUBItem(itemref.Item)
+		if err != nil {
+			continue
+		}
+		if navTOC == nil {
+			navTOC = findNavTOC(doc, itemref.HREF)
+		}
+		r.render(doc, itemref.HREF)
+	}
+	r.finish()
+
+	toc := readNCX(&book.Package)
+	if len(toc) == 0 {
+		toc = navTOC
+	}
+	if len(toc) == 0 {
+		toc = findNavTOCOutsideSpine(&book.Package)
+	}
+	chapters := resolveTOC(toc, r.anchors)
+	if len(chapters) == 0 {
+		chapters = r.headings
+	}
+
+	doc := r.document()
+	doc.Chapters = chapters
+	doc.Metadata = Metadata{
+		Title:     strings.TrimSpace(book.Title),
+		Author:    strings.TrimSpace(book.Creator),
+		Language:  strings.TrimSpace(book.Language),
+		Publisher: strings.TrimSpace(book.Publisher),
+	}
+	return doc, nil
+}
+
+func parseEPUBItem(item *epub.Item) (*html.Node, error) {
+	reader, err := item.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return html.Parse(reader)
+}
+
+// resolveTOC turns TOC targets into line numbers, falling back to the start of
+// the file when a fragment was not seen, and drops targets outside the spine.
+func resolveTOC(toc []tocEntry, anchors map[string]int) []Chapter {
+	var chapters []Chapter
+	for _, entry := range toc {
+		line, ok := anchors[entry.target]
+		if !ok {
+			file, _, _ := strings.Cut(entry.target, "#")
+			if line, ok = anchors[file]; !ok {
+				continue
+			}
+		}
+		chapters = append(chapters, Chapter{Title: entry.title, Line: line, Level: entry.level})
+	}
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Line < chapters[j].Line
+	})
+	return chapters
+}
+
+type ncxNavPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Children []ncxNavPoint `xml:"navPoint"`
+}
+
+type ncxDocument struct {
+	NavPoints []ncxNavPoint `xml:"navMap>navPoint"`
+}
+
+// readNCX reads the EPUB 2 table of contents, if the package declares one.
+func readNCX(pkg *epub.Package) []tocEntry {
+	for i := range pkg.Manifest.Items {
+		item := &pkg.Manifest.Items[i]
+		if item.MediaType != ncxMediaType {
+			continue
+		}
+		reader, err := item.Open()
+		if err != nil {
+			return nil
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil
+		}
+		var ncx ncxDocument
+		if err := xml.Unmarshal(data, &ncx); err != nil {
+			return nil
+		}
+
+		var toc []tocEntry
+		var walk func(points []ncxNavPoint, level int)
+		walk = func(points []ncxNavPoint, level int) {
+			for _, p := range points {
+				toc = append(toc, tocEntry{
+					title:  strings.TrimSpace(p.Label),
+					target: resolveHref(item.HREF, p.Content.Src),
+					level:  level,
+				})
+				walk(p.Children, level+1)
+			}
+		}
+		walk(ncx.NavPoints, 0)
+		return toc
+	}
+	return nil
+}
+
+// findNavTOCOutsideSpine looks for the EPUB 3 navigation document among the
+// manifest items that are not part of the reading order.
+func findNavTOCOutsideSpine(pkg *epub.Package) []tocEntry {
+	inSpine := make(map[string]bool)
+	for _, itemref := range pkg.Spine.Itemrefs {
+		inSpine[itemref.IDREF] = true
+	}
+	for i := range pkg.Manifest.Items {
+		item := &pkg.Manifest.Items[i]
+		if inSpine[item.ID] || !strings.Contains(item.MediaType, "html") {
+			continue
+		}
+		doc, err := parseEPUBItem(item)
+		if err != nil {
+			continue
+		}
+		if toc := findNavTOC(doc, item.HREF); toc != nil {
+			return toc
+		}
+	}
+	return nil
+}
+
+// findNavTOC extracts the links of a <nav epub:type="toc"> element.
+func findNavTOC(doc *html.Node, href string) []tocEntry {
+	nav := findNode(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == "nav" && strings.Contains(attr(n, "epub:type"), "toc")
+	})
+	if nav == nil {
+		return nil
+	}
+
+	var toc []tocEntry
+	var walk func(n *html.Node, level int)
+	walk = func(n *html.Node, level int) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "a":
+				toc = append(toc, tocEntry{
+					title:  strings.Join(strings.Fields(nodeText(c)), " "),
+					target: resolveHref(href, attr(c, "href")),
+					level:  level,
+				})
+			case "ol", "ul":
+				walk(c, level+1)
+			default:
+				walk(c, level)
+			}
+		}
+	}
+	// The outermost list is level 0.
+	walk(nav, -1)
+	return toc
+}
+
+// resolveHref makes a link found in the document at base relative to the
+// package document, like the manifest hrefs are.
+func resolveHref(base, link string) string {
+	file, fragment, hasFragment := strings.Cut(link, "#")
+	if unescaped, err := url.PathUnescape(file); err == nil {
+		file = unescaped
+	}
+	if file == "" {
+		file = base
+	} else {
+		file = path.Join(path.Dir(base), file)
+	}
+	if hasFragment {
+		return file + "#" + fragment
+	}
+	return file
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key || (a.Namespace != "" && a.Namespace+":"+a.Key == key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
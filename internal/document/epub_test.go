@@ -0,0 +1,135 @@
+package document
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/net/html"
+)
+
+const testContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+
+const testNCX = `<?xml version="1.0"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1"><navMap>
+<navPoint id="p1"><navLabel><text> One </text></navLabel><content src="text/one.xhtml"/>
+<navPoint id="p2"><navLabel><text>Part</text></navLabel><content src="text/one.xhtml#part"/></navPoint>
+</navPoint>
+<navPoint id="p3"><navLabel><text>Two</text></navLabel><content src="text/two%20b.xhtml"/></navPoint>
+</navMap></ncx>`
+
+const testNav = `<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol>
+<li><a href="one.xhtml">Chapter
+  one</a><ol><li><a href="#part">Part</a></li></ol></li>
+<li><a href="../text/two%20b.xhtml">Two</a></li>
+</ol></nav>
+</body></html>`
+
+// testEPUB builds an EPUB with two chapters, the NCX table of contents when
+// ncx is set and the navigation document otherwise.
+func testEPUB(t *testing.T, ncx bool) []byte {
+	t.Helper()
+	manifest := `<item id="nav" href="text/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`
+	if ncx {
+		manifest = `<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>`
+	}
+	opf := `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Book</dc:title><dc:creator>Author</dc:creator></metadata>
+<manifest>` + manifest + `
+<item id="one" href="text/one.xhtml" media-type="application/xhtml+xml"/>
+<item id="two" href="text/two b.xhtml" media-type="application/xhtml+xml"/>
+</manifest>
+<spine><itemref idref="one"/><itemref idref="two"/></spine>
+</package>`
+	return zipFiles(t,
+		"mimetype", "application/epub+zip",
+		"META-INF/container.xml", testContainer,
+		"OEBPS/content.opf", opf,
+		"OEBPS/toc.ncx", testNCX,
+		"OEBPS/text/nav.xhtml", testNav,
+		"OEBPS/text/one.xhtml", "<html><body><p>Intro</p><h2 id=\"part\">Part</h2><p>Text</p></body></html>",
+		"OEBPS/text/two b.xhtml", "<html><body><p>Second</p></body></html>",
+	)
+}
+
+func TestResolveTOC(t *testing.T) {
+	toc := []tocEntry{
+		{"Two", "two.xhtml", 0},
+		{"One", "one.xhtml", 0},
+		{"Part", "one.xhtml#part", 1},
+		{"Missing fragment", "two.xhtml#gone", 1},
+		{"Outside the spine", "cover.xhtml", 0},
+	}
+	anchors := map[string]int{"one.xhtml": 0, "one.xhtml#part": 4, "two.xhtml": 10}
+	want := []Chapter{{"One", 0, 0}, {"Part", 4, 1}, {"Two", 10, 0}, {"Missing fragment", 10, 1}}
+	if got := resolveTOC(toc, anchors); !slices.Equal(got, want) {
+		t.Errorf("resolveTOC = %v, want %v", got, want)
+	}
+}
+
+func TestFindNavTOC(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(testNav))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []tocEntry{
+		{"Chapter one", "text/one.xhtml", 0},
+		{"Part", "text/nav.xhtml#part", 1},
+		{"Two", "text/two b.xhtml", 0},
+	}
+	if got := findNavTOC(doc, "text/nav.xhtml"); !slices.Equal(got, want) {
+		t.Errorf("findNavTOC = %v, want %v", got, want)
+	}
+
+	doc, err = html.Parse(strings.NewReader("<nav epub:type=\"landmarks\"><a href=\"one.xhtml\">One</a></nav>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := findNavTOC(doc, "nav.xhtml"); got != nil {
+		t.Errorf("findNavTOC = %v, want nil without a toc nav", got)
+	}
+}
+
+func TestReadNCX(t *testing.T) {
+	for _, ncx := range []bool{true, false} {
+		data := testEPUB(t, ncx)
+		rd, err := epub.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []tocEntry
+		if ncx {
+			want = []tocEntry{{"One", "text/one.xhtml", 0}, {"Part", "text/one.xhtml#part", 1}, {"Two", "text/two b.xhtml", 0}}
+		}
+		if got := readNCX(&rd.Rootfiles[0].Package); !slices.Equal(got, want) {
+			t.Errorf("ncx %v: readNCX = %v, want %v", ncx, got, want)
+		}
+	}
+}
+
+func TestEPUB(t *testing.T) {
+	tests := []struct {
+		name string
+		ncx  bool
+		want []Chapter
+	}{
+		{"ncx", true, []Chapter{{"One", 0, 0}, {"Part", 2, 1}, {"Two", 6, 0}}},
+		{"navigation document", false, []Chapter{{"Chapter one", 0, 0}, {"Two", 6, 0}}},
+	}
+	for _, tt := range tests {
+		doc := load(t, "book.epub", testEPUB(t, tt.ncx))
+		if !slices.Equal(doc.Chapters, tt.want) {
+			t.Errorf("%s: Chapters = %v, want %v\nlines %q", tt.name, doc.Chapters, tt.want, doc.Lines)
+		}
+		if doc.Metadata.Title != "Book" || doc.Metadata.Author != "Author" {
+			t.Errorf("%s: Metadata = %+v, want Book by Author", tt.name, doc.Metadata)
+		}
+	}
+}
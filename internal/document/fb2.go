@@ -0,0 +1,228 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+const fb2Format = "fb2"
+
+func init() {
+	Register(fb2Format, LoaderFunc(loadFB2), []string{".fb2"})
+}
+
+type fb2Author struct {
+	FirstName  string `xml:"first-name"`
+	MiddleName string `xml:"middle-name"`
+	LastName   string `xml:"last-name"`
+	Nickname   string `xml:"nickname"`
+}
+
+func (a fb2Author) String() string {
+	name := strings.Join(strings.Fields(a.FirstName+" "+a.MiddleName+" "+a.LastName), " ")
+	if name == "" {
+		return strings.TrimSpace(a.Nickname)
+	}
+	return name
+}
+
+type fb2Description struct {
+	TitleInfo struct {
+		Authors   []fb2Author `xml:"author"`
+		BookTitle string      `xml:"book-title"`
+		Lang      string      `xml:"lang"`
+	} `xml:"title-info"`
+	PublishInfo struct {
+		Publisher string `xml:"publisher"`
+	} `xml:"publish-info"`
+}
+
+func (d fb2Description) metadata() Metadata {
+	var authors []string
+	for _, a := range d.TitleInfo.Authors {
+		if name := a.String(); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	return Metadata{
+		Title:     strings.TrimSpace(d.TitleInfo.BookTitle),
+		Author:    strings.Join(authors, ", "),
+		Language:  strings.TrimSpace(d.TitleInfo.Lang),
+		Publisher: strings.TrimSpace(d.PublishInfo.Publisher),
+	}
+}
+
+// fb2Renderer walks the body of a FictionBook. Sections become chapters named
+// after their <title>, paragraphs become lines and <emphasis>/<strong> spans.
+type fb2Renderer struct {
+	lineBuilder
+
+	chapters []Chapter
+	metadata Metadata
+
+	inline     strings.Builder
+	spans      []Span
+	openSpans  []Span // Spans whose end tag has not been seen yet
+	inBlock    bool   // Inside an element holding inline text
+	sections   int    // Nesting depth of <section>
+	quotes     int    // Nesting depth of <cite> and <epigraph>
+	inTitle    bool
+	titleParts []string
+	titleLine  int
+}
+
+func loadFB2(src Source) (*Document, error) {
+	r := &fb2Renderer{lineBuilder: newLineBuilder()}
+	if err := r.render(src.Data); err != nil {
+		return nil, fmt.Errorf("error parsing FB2: %v", err)
+	}
+
+	doc := r.document()
+	doc.Chapters = r.chapters
+	doc.Metadata = r.metadata
+	return doc, nil
+}
+
+func (r *fb2Renderer) render(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Many FB2 books are declared as windows-1251 or koi8-r.
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "description":
+				var desc fb2Description
+				if err := decoder.DecodeElement(&desc, &t); err != nil {
+					return err
+				}
+				r.metadata = desc.metadata()
+			case "binary":
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+			default:
+				r.start(t)
+			}
+		case xml.EndElement:
+			r.end(t.Name.Local)
+		case xml.CharData:
+			if r.inBlock {
+				appendText(&r.inline, string(t))
+			}
+		}
+	}
+	r.finish()
+	return nil
+}
+
+func (r *fb2Renderer) start(t xml.StartElement) {
+	switch t.Name.Local {
+	case "body", "stanza":
+		r.paragraphBreak()
+	case "section":
+		r.sections++
+		r.paragraphBreak()
+	case "cite", "epigraph":
+		r.quotes++
+		r.paragraphBreak()
+	case "title":
+		r.inTitle = true
+		r.titleParts = nil
+		r.paragraphBreak()
+		r.titleLine = r.nextLine()
+	case "empty-line":
+		r.paragraphBreak()
+	case "p", "v", "subtitle", "text-author", "td", "th":
+		r.inBlock = true
+		r.inline.Reset()
+		r.spans = nil
+		r.openSpans = nil
+	case "emphasis", "strong", "code":
+		if r.inBlock {
+			r.openSpans = append(r.openSpans, Span{Start: r.inline.Len(), Style: fb2SpanStyles[t.Name.Local]})
+		}
+	}
+}
+
+var fb2SpanStyles = map[string]SpanStyle{
+	"emphasis": SpanEmphasis,
+	"strong":   SpanStrong,
+	"code":     SpanCode,
+}
+
+func (r *fb2Renderer) end(name string) {
+	switch name {
+	case "body", "stanza":
+		r.paragraphBreak()
+	case "section":
+		r.sections--
+		r.paragraphBreak()
+	case "cite", "epigraph":
+		r.quotes--
+		r.paragraphBreak()
+	case "title":
+		r.inTitle = false
+		if title := strings.Join(r.titleParts, " "); title != "" {
+			r.chapters = append(r.chapters, Chapter{Title: title, Line: r.titleLine, Level: max(0, r.sections-1)})
+		}
+		r.paragraphBreak()
+	case "emphasis", "strong", "code":
+		if n := len(r.openSpans); n > 0 {
+			span := r.openSpans[n-1]
+			r.openSpans = r.openSpans[:n-1]
+			span.End = r.inline.Len()
+			if span.End > span.Start {
+				r.spans = append(r.spans, span)
+			}
+		}
+	case "p", "v", "subtitle", "text-author", "td", "th":
+		r.flush(name)
+	}
+}
+
+// flush emits the paragraph closed by the end tag name.
+func (r *fb2Renderer) flush(name string) {
+	r.inBlock = false
+	line := strings.TrimRight(r.inline.String(), " ")
+	r.inline.Reset()
+	if line == "" {
+		return
+	}
+	spans := r.spans
+	for i := range spans {
+		spans[i].End = min(spans[i].End, len(line))
+	}
+
+	kind := LineText
+	switch {
+	case r.inTitle || name == "subtitle":
+		kind = LineHeading
+	case r.quotes > 0:
+		kind = LineQuote
+	}
+	if r.inTitle {
+		r.titleParts = append(r.titleParts, line)
+	}
+	r.emit(line, kind, spans...)
+
+	// Verses of a stanza and lines of a title stay together.
+	if name != "v" && !r.inTitle {
+		r.paragraphBreak()
+	}
+}
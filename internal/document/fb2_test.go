@@ -0,0 +1,77 @@
+package document
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+const testFB2 = `<?xml version="1.0" encoding="utf-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0">
+<description>
+  <title-info>
+    <author><first-name>Lev</first-name><last-name>Tolstoy</last-name></author>
+    <book-title>War and Peace</book-title>
+    <lang>ru</lang>
+  </title-info>
+  <publish-info><publisher>Someone</publisher></publish-info>
+</description>
+<body>
+  <section>
+    <title><p>Part One</p></title>
+    <epigraph><p>A quote</p></epigraph>
+    <section>
+      <title><p>Chapter I</p><p>The salon</p></title>
+      <p>Well, <emphasis>Prince</emphasis>, so <strong>Genoa</strong> and Lucca.</p>
+      <poem><stanza><v>First verse</v><v>Second verse</v></stanza></poem>
+    </section>
+  </section>
+</body>
+<binary id="cover.jpg" content-type="image/jpeg">AAAA</binary>
+</FictionBook>`
+
+func TestFB2(t *testing.T) {
+	doc := load(t, "book.fb2", []byte(testFB2))
+
+	lines := []string{
+		"Part One", "", "A quote", "", "Chapter I", "The salon", "",
+		"Well, Prince, so Genoa and Lucca.", "", "First verse", "Second verse",
+	}
+	if !slices.Equal(doc.Lines, lines) {
+		t.Errorf("Lines = %q, want %q", doc.Lines, lines)
+	}
+	kinds := map[int]LineKind{0: LineHeading, 2: LineQuote, 4: LineHeading, 5: LineHeading}
+	if !maps.Equal(doc.Kinds, kinds) {
+		t.Errorf("Kinds = %v, want %v", doc.Kinds, kinds)
+	}
+	chapters := []Chapter{{"Part One", 0, 0}, {"Chapter I The salon", 4, 1}}
+	if !slices.Equal(doc.Chapters, chapters) {
+		t.Errorf("Chapters = %v, want %v", doc.Chapters, chapters)
+	}
+	spans := []Span{{6, 12, SpanEmphasis}, {17, 22, SpanStrong}}
+	if !slices.Equal(doc.Spans[7], spans) {
+		t.Errorf("Spans = %v, want %v", doc.Spans[7], spans)
+	}
+	metadata := Metadata{Title: "War and Peace", Author: "Lev Tolstoy", Language: "ru", Publisher: "Someone"}
+	if doc.Metadata != metadata {
+		t.Errorf("Metadata = %+v, want %+v", doc.Metadata, metadata)
+	}
+}
+
+func TestFB2ConsecutiveSections(t *testing.T) {
+	data := `<FictionBook><body>
+<section><title><p>One</p></title><p>First</p></section>
+<section><title><p>Two</p></title><p>Second</p><empty-line/></section>
+<section><title><p>Three</p></title></section>
+</body></FictionBook>`
+	doc := load(t, "book.fb2", []byte(data))
+
+	lines := []string{"One", "", "First", "", "Two", "", "Second", "", "Three"}
+	if !slices.Equal(doc.Lines, lines) {
+		t.Errorf("Lines = %q, want %q", doc.Lines, lines)
+	}
+	chapters := []Chapter{{"One", 0, 0}, {"Two", 4, 0}, {"Three", 8, 0}}
+	if !slices.Equal(doc.Chapters, chapters) {
+		t.Errorf("Chapters = %v, want %v", doc.Chapters, chapters)
+	}
+}
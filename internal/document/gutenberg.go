@@ -0,0 +1,216 @@
+package document
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	gutenbergStartRe = regexp.MustCompile(`(?i)^\s*\*{3}\s*START OF (THE|THIS) PROJECT GUTENBERG`)
+	gutenbergEndRe   = regexp.MustCompile(`(?i)^\s*(\*{3}\s*END OF (THE|THIS) PROJECT GUTENBERG|End of (the )?Project Gutenberg)`)
+)
+
+// CleanGutenberg removes the Project Gutenberg header and license of a plain
+// text document and rejoins its hard-wrapped lines into paragraphs. Documents
+// with a structure of their own (chapters or styled lines) are left as they
+// are. It returns the line of the original document each line left starts at,
+// to translate positions between both layouts, or nil if nothing was done.
+func CleanGutenberg(doc *Document) []int {
+	if len(doc.Chapters) > 0 || len(doc.Kinds) > 0 || len(doc.Spans) > 0 {
+		return nil
+	}
+	lines, first := stripGutenberg(doc.Lines)
+	var starts []int
+	doc.Lines, starts = reflow(lines)
+	for i := range starts {
+		starts[i] += first
+	}
+	if len(doc.Lines) == 0 {
+		doc.Lines, starts = []string{""}, []int{0}
+	}
+	return starts
+}
+
+// StripGutenberg returns the lines between the START and END markers of a
+// Project Gutenberg text, or lines unchanged if there are no markers.
+func StripGutenberg(lines []string) []string {
+	stripped, _ := stripGutenberg(lines)
+	return stripped
+}
+
+// stripGutenberg is StripGutenberg, also returning the index of the first
+// line kept.
+func stripGutenberg(lines []string) ([]string, int) {
+	start, end := 0, len(lines)
+	for i, line := range lines {
+		if gutenbergStartRe.MatchString(line) {
+			start = i + 1
+			break
+		}
+	}
+	for i := len(lines) - 1; i >= start; i-- {
+		if gutenbergEndRe.MatchString(lines[i]) {
+			end = i
+			break
+		}
+	}
+
+	for start < end && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[start:end], start
+}
+
+// Reflow joins hard-wrapped lines back into paragraphs. A line is joined with
+// the next one when it is long enough to have been wrapped and the next one is
+// not indented deeper, which keeps verses, headings and tables intact. Words
+// hyphenated across lines are rejoined.
+func Reflow(lines []string) []string {
+	out, _ := reflow(lines)
+	return out
+}
+
+// reflow is Reflow, also returning the index of the line each paragraph
+// starts at.
+func reflow(lines []string) ([]string, []int) {
+	starts := make([]int, len(lines))
+	for i := range starts {
+		starts[i] = i
+	}
+	width := wrapWidth(lines)
+	if width == 0 {
+		return lines, starts
+	}
+
+	words := vocabulary(lines)
+	var out []string
+	starts = starts[:0]
+	var paragraph strings.Builder
+	indent := 0
+	for i, line := range lines {
+		if paragraph.Len() == 0 {
+			starts = append(starts, i)
+			if strings.TrimSpace(line) == "" {
+				out = append(out, "")
+				continue
+			}
+			indent = indentation(line)
+			paragraph.WriteString(strings.TrimRight(line, " \t"))
+		} else {
+			appendWrapped(&paragraph, strings.TrimSpace(line), words)
+		}
+
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+		wrapped := utf8.RuneCountInString(line) > width*2/3 || hyphenated(line, next)
+		if !wrapped || strings.TrimSpace(next) == "" || indentation(next) > indent {
+			out = append(out, paragraph.String())
+			paragraph.Reset()
+		}
+	}
+	if paragraph.Len() > 0 {
+		out = append(out, paragraph.String())
+	}
+	return out, starts
+}
+
+// appendWrapped adds the continuation of a wrapped line to a paragraph. words
+// are the words of the whole text, which tell a word split across lines from
+// a hyphenated one.
+func appendWrapped(paragraph *strings.Builder, next string, words map[string]bool) {
+	text := paragraph.String()
+	switch {
+	case strings.HasSuffix(text, "--"):
+		// Gutenberg's em dash; the words around it are not spaced.
+	case hyphenated(text, next):
+		// "senti-" + "mental" is a split word when "sentimental" is found
+		// elsewhere in the text, while "well-" + "known" and "Jack-" +
+		// "Lantern" keep their hyphen.
+		joined := strings.ToLower(lastWord(strings.TrimSuffix(text, "-")) + firstWord(next))
+		if first, _ := utf8.DecodeRuneInString(next); unicode.IsLower(first) && words[joined] {
+			paragraph.Reset()
+			paragraph.WriteString(strings.TrimSuffix(text, "-"))
+		}
+	default:
+		paragraph.WriteString(" ")
+	}
+	paragraph.WriteString(next)
+}
+
+// hyphenated reports whether line ends with a hyphen joining its last word to
+// the first one of next.
+func hyphenated(line, next string) bool {
+	line = strings.TrimRight(line, " \t")
+	if !strings.HasSuffix(line, "-") || strings.HasSuffix(line, "--") {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(strings.TrimSuffix(line, "-"))
+	first, _ := utf8.DecodeRuneInString(strings.TrimSpace(next))
+	return unicode.IsLetter(last) && unicode.IsLetter(first)
+}
+
+// vocabulary returns the words of lines, lowercased. Hyphenated words are
+// split into their parts.
+func vocabulary(lines []string) map[string]bool {
+	words := make(map[string]bool)
+	for _, line := range lines {
+		for _, word := range strings.FieldsFunc(line, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			words[strings.ToLower(word)] = true
+		}
+	}
+	return words
+}
+
+// lastWord returns the letters s ends with.
+func lastWord(s string) string {
+	i := len(s)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		i -= size
+	}
+	return s[i:]
+}
+
+// firstWord returns the letters s starts with.
+func firstWord(s string) string {
+	if i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// wrapWidth estimates the column the text was hard-wrapped at, as the 90th
+// percentile of the line lengths. It returns 0 when lines look too short to
+// have been wrapped, e.g. for verse or already flowed text.
+func wrapWidth(lines []string) int {
+	var lengths []int
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lengths = append(lengths, utf8.RuneCountInString(line))
+		}
+	}
+	if len(lengths) == 0 {
+		return 0
+	}
+	sort.Ints(lengths)
+	width := lengths[len(lengths)*9/10]
+	if width < 40 || width > 120 {
+		return 0
+	}
+	return width
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
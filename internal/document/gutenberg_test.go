@@ -0,0 +1,141 @@
+package document
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStripGutenberg(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"no markers", []string{"one", "two"}, []string{"one", "two"}},
+		{
+			"markers",
+			[]string{
+				"The Project Gutenberg eBook", "", "*** START OF THE PROJECT GUTENBERG EBOOK X ***", "",
+				"Text", "", "*** END OF THE PROJECT GUTENBERG EBOOK X ***", "License",
+			},
+			[]string{"Text"},
+		},
+		{
+			"old end marker",
+			[]string{"*** START OF THIS PROJECT GUTENBERG EBOOK X ***", "Text", "End of the Project Gutenberg EBook"},
+			[]string{"Text"},
+		},
+	}
+	for _, tt := range tests {
+		if got := StripGutenberg(tt.lines); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: StripGutenberg = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			"short lines",
+			[]string{"Roses are red,", "violets are blue."},
+			[]string{"Roses are red,", "violets are blue."},
+		},
+		{
+			"paragraphs",
+			[]string{
+				"It was the best of times, it was the worst of times, it was",
+				"the age of wisdom, it was the age of foolishness, it was the",
+				"epoch of belief.",
+				"",
+				"There were a king with a large jaw and a queen with a plain",
+				"face, on the throne of England; there were a king with a large",
+				"jaw and a queen with a fair face.",
+			},
+			[]string{
+				"It was the best of times, it was the worst of times, it was the age of wisdom, it was the age of foolishness, it was the epoch of belief.",
+				"",
+				"There were a king with a large jaw and a queen with a plain face, on the throne of England; there were a king with a large jaw and a queen with a fair face.",
+			},
+		},
+		{
+			"hyphens and dashes",
+			[]string{
+				"The sentimental words of this line are long enough to be senti-",
+				"mental words; and this other line ends in a Gutenberg dash--",
+				"which is kept without spaces, as is the Jack-o'-Lantern of a-",
+				"Lantern.",
+			},
+			[]string{
+				"The sentimental words of this line are long enough to be sentimental words; and this other line ends in a Gutenberg dash--which is kept without spaces, as is the Jack-o'-Lantern of a-Lantern.",
+			},
+		},
+		{
+			"hyphenated words",
+			[]string{
+				"A well-known story that is long enough to be wrapped, a well-",
+				"known story about the Jack-o'-Lantern, also called the Jack-",
+				"Lantern, that is told every year at the end of October.",
+			},
+			[]string{
+				"A well-known story that is long enough to be wrapped, a well-known story about the Jack-o'-Lantern, also called the Jack-Lantern, that is told every year at the end of October.",
+			},
+		},
+		{
+			"indented lines",
+			[]string{
+				"A paragraph long enough to have been wrapped at the usual width",
+				"    An indented verse that is quoted in the middle of the text",
+				"    and another indented line of the same verse that follows.",
+			},
+			[]string{
+				"A paragraph long enough to have been wrapped at the usual width",
+				"    An indented verse that is quoted in the middle of the text and another indented line of the same verse that follows.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := Reflow(tt.lines); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Reflow =\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestCleanGutenberg(t *testing.T) {
+	doc := &Document{Lines: []string{"*** START OF THE PROJECT GUTENBERG EBOOK X ***", "*** END OF THE PROJECT GUTENBERG EBOOK X ***"}}
+	if starts := CleanGutenberg(doc); !slices.Equal(starts, []int{0}) {
+		t.Errorf("starts = %v, want [0]", starts)
+	}
+	if !slices.Equal(doc.Lines, []string{""}) {
+		t.Errorf("Lines = %q, want a single empty line", doc.Lines)
+	}
+
+	book := &Document{Lines: []string{
+		"The Project Gutenberg eBook", "*** START OF THE PROJECT GUTENBERG EBOOK X ***", "",
+		"It was the best of times, it was the worst of times, it was",
+		"the age of wisdom, it was the age of foolishness, it was the",
+		"epoch of belief.",
+		"",
+		"There were a king with a large jaw and a queen with a plain",
+		"face, on the throne of England.",
+		"*** END OF THE PROJECT GUTENBERG EBOOK X ***",
+	}}
+	if starts := CleanGutenberg(book); !slices.Equal(starts, []int{3, 6, 7}) {
+		t.Errorf("starts = %v, want [3 6 7]", starts)
+	}
+	if len(book.Lines) != 3 {
+		t.Errorf("Lines = %q, want 3 lines", book.Lines)
+	}
+
+	structured := &Document{Lines: []string{"*** START OF THE PROJECT GUTENBERG EBOOK X ***", "Title"}, Chapters: []Chapter{{"Title", 1, 0}}}
+	if starts := CleanGutenberg(structured); starts != nil {
+		t.Errorf("starts = %v, want nil", starts)
+	}
+	if len(structured.Lines) != 2 {
+		t.Errorf("Lines = %q, documents with chapters should be left as they are", structured.Lines)
+	}
+}
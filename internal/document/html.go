@@ -0,0 +1,244 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// skippedElements never contain readable text.
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true,
+	"template": true, "svg": true, "math": true, "iframe": true, "object": true,
+}
+
+// blockElements start and end a paragraph of their own.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "aside": true,
+	"header": true, "footer": true, "main": true, "nav": true, "body": true,
+	"blockquote": true, "figure": true, "figcaption": true, "address": true,
+	"table": true, "caption": true, "dl": true, "dd": true, "dt": true,
+	"center": true,
+}
+
+var headingLevels = map[string]int{"h1": 0, "h2": 1, "h3": 2, "h4": 3, "h5": 4, "h6": 5}
+
+// htmlRenderer flattens HTML documents into lines of text: inline runs are
+// joined into a single line per paragraph and paragraphs are separated by a
+// blank line. The same renderer can be fed several documents in a row, as the
+// spine of an EPUB.
+type htmlRenderer struct {
+	lineBuilder
+
+	// Anchors mark where each document and element id starts, keyed by
+	// "name" and "name#id".
+	name string
+
+	// Headings up to h3, usable as chapters when there is no better TOC.
+	headings []Chapter
+
+	inline   strings.Builder
+	marker   string // List bullet at the start of inline, if any
+	lists    []listState
+	items    int // Open list items, their paragraphs are not spaced apart
+	preDepth int
+	preLines int
+}
+
+type listState struct {
+	ordered bool
+	next    int
+}
+
+func newHTMLRenderer() *htmlRenderer {
+	return &htmlRenderer{lineBuilder: newLineBuilder()}
+}
+
+// render appends the content of doc. name identifies the document for anchors.
+func (r *htmlRenderer) render(doc *html.Node, name string) {
+	r.name = name
+	r.anchor(name)
+	r.walk(doc)
+	r.flush(LineText)
+	r.paragraphBreak()
+}
+
+func (r *htmlRenderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.walk(c)
+		}
+		return
+	}
+
+	if skippedElements[n.Data] {
+		return
+	}
+	if id := attr(n, "id"); id != "" {
+		r.anchor(r.name + "#" + id)
+	}
+
+	if level, ok := headingLevels[n.Data]; ok {
+		r.flush(LineText)
+		r.paragraphBreak()
+		r.children(n)
+		title := strings.TrimSpace(r.inline.String())
+		if title != "" && level <= 2 {
+			r.headings = append(r.headings, Chapter{Title: title, Line: r.nextLine(), Level: level})
+		}
+		r.flush(LineHeading)
+		r.paragraphBreak()
+		return
+	}
+
+	switch n.Data {
+	case "br":
+		r.flush(LineText)
+	case "hr":
+		r.flush(LineText)
+		r.paragraphBreak()
+	case "pre":
+		r.flush(LineText)
+		r.paragraphBreak()
+		r.preDepth++
+		r.preLines = 0
+		r.children(n)
+		r.preDepth--
+		if r.inline.Len() > 0 {
+			r.flushPreformatted()
+		}
+		r.paragraphBreak()
+	case "ul", "ol":
+		r.flush(LineText)
+		if len(r.lists) == 0 {
+			r.paragraphBreak()
+		}
+		r.lists = append(r.lists, listState{ordered: n.Data == "ol", next: 1})
+		r.children(n)
+		r.flush(LineText)
+		r.lists = r.lists[:len(r.lists)-1]
+		if len(r.lists) == 0 {
+			r.paragraphBreak()
+		}
+	case "li":
+		r.flush(LineText)
+		r.dropMarker() // Left by an outer item with no text before this one
+		r.marker = r.bullet()
+		r.inline.WriteString(r.marker)
+		r.items++
+		r.children(n)
+		r.items--
+		r.flush(LineText)
+		r.dropMarker()
+	case "tr":
+		r.flush(LineText)
+		r.children(n)
+		r.flush(LineText)
+	case "td", "th":
+		if r.inline.Len() > 0 {
+			r.inline.WriteString(" | ")
+		}
+		r.children(n)
+	default:
+		if blockElements[n.Data] {
+			r.flush(LineText)
+			r.blockBreak()
+			r.children(n)
+			r.flush(LineText)
+			r.blockBreak()
+		} else {
+			r.children(n)
+		}
+	}
+}
+
+func (r *htmlRenderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// text adds a text node to the current paragraph, collapsing whitespace the
+// way a browser would, except inside <pre> where lines are kept as they are.
+func (r *htmlRenderer) text(data string) {
+	if r.preDepth > 0 {
+		for i, line := range strings.Split(data, "\n") {
+			if i > 0 {
+				r.flushPreformatted()
+			}
+			r.inline.WriteString(strings.TrimRight(line, "\r"))
+		}
+		return
+	}
+
+	appendText(&r.inline, data)
+}
+
+// blockBreak separates blocks with a blank line, except within a list item.
+func (r *htmlRenderer) blockBreak() {
+	if r.items == 0 {
+		r.paragraphBreak()
+	}
+}
+
+// flush emits the current paragraph, if any, as a line of the given kind. A
+// list bullet with no text yet is kept for the first line of the item, which
+// often comes in a block of its own as in <li><p>.
+func (r *htmlRenderer) flush(kind LineKind) {
+	text := r.inline.String()
+	line := strings.Join(strings.Fields(text), " ")
+	marker := strings.TrimSpace(r.marker)
+	if marker != "" && line == marker {
+		return
+	}
+	r.inline.Reset()
+	r.marker = ""
+	if line == "" {
+		return
+	}
+	if marker != "" {
+		// Keep the indentation of nested list items.
+		line = text[:len(text)-len(strings.TrimLeft(text, " "))] + line
+	}
+	r.emit(line, kind)
+}
+
+// dropMarker discards a list bullet still waiting for the text of its item.
+func (r *htmlRenderer) dropMarker() {
+	if r.marker != "" {
+		r.inline.Reset()
+		r.marker = ""
+	}
+}
+
+func (r *htmlRenderer) flushPreformatted() {
+	line := r.inline.String()
+	r.inline.Reset()
+	// Browsers drop the newline right after the opening tag.
+	if line == "" && r.preLines == 0 {
+		return
+	}
+	r.preLines++
+	r.emit(line, LineText)
+}
+
+// bullet returns the marker for a new item of the innermost list.
+func (r *htmlRenderer) bullet() string {
+	if len(r.lists) == 0 {
+		return ""
+	}
+	indent := strings.Repeat("  ", len(r.lists)-1)
+	list := &r.lists[len(r.lists)-1]
+	if list.ordered {
+		marker := fmt.Sprintf("%s%d. ", indent, list.next)
+		list.next++
+		return marker
+	}
+	return indent + "• "
+}
@@ -0,0 +1,53 @@
+package document
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func renderHTML(t *testing.T, src string) []string {
+	t.Helper()
+	root, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("parsing %q: %v", src, err)
+	}
+	r := newHTMLRenderer()
+	r.render(root, "test")
+	return r.lines
+}
+
+func TestHTMLRenderer(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{"paragraphs", "<p>One</p><p>Two  words</p>", []string{"One", "", "Two words"}},
+		{"line break", "<p>One<br>Two</p>", []string{"One", "Two"}},
+		{"heading", "<h1>Title</h1><p>Text</p>", []string{"Title", "", "Text"}},
+		{"list", "<p>Intro</p><ul><li>One</li><li>Two</li></ul><p>End</p>",
+			[]string{"Intro", "", "• One", "• Two", "", "End"}},
+		{"ordered list", "<ol><li>One</li><li>Two</li></ol>", []string{"1. One", "2. Two"}},
+		{"nested list", "<ul><li>One<ul><li>Inner</li></ul></li><li>Two</li></ul>",
+			[]string{"• One", "  • Inner", "• Two"}},
+		{"paragraphs in items", "<ul><li><p>One</p></li><li><p>Two</p></li></ul>",
+			[]string{"• One", "• Two"}},
+		{"several paragraphs in an item", "<ol><li><p>One</p><p>More</p></li><li><div>Two</div></li></ol>",
+			[]string{"1. One", "More", "2. Two"}},
+		{"empty item", "<ul><li></li><li>Two</li></ul><p>End</p>", []string{"• Two", "", "End"}},
+		{"list only in an item", "<ul><li><ul><li>Inner</li></ul></li></ul>", []string{"  • Inner"}},
+		{"preformatted", "<pre>\n a\n  b</pre>", []string{" a", "  b"}},
+		{"table", "<table><tr><td>a</td><td>b</td></tr></table>", []string{"a | b"}},
+		{"skipped", "<script>x()</script><p>Text</p>", []string{"Text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderHTML(t, tt.html); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const htmlFormat = "html"
+
+func init() {
+	Register(htmlFormat, LoaderFunc(loadHTML), []string{".html", ".htm", ".xhtml"},
+		Magic{Bytes: []byte("<!DOCTYPE html")},
+		Magic{Bytes: []byte("<!doctype html")})
+}
+
+func loadHTML(src Source) (*Document, error) {
+	data, err := decodeHTML(src.Data, src.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %v", err)
+	}
+
+	r := newHTMLRenderer()
+	r.render(root, src.Name)
+	r.finish()
+
+	doc := r.document()
+	doc.Chapters = r.headings
+	doc.Metadata = htmlMetadata(root)
+	return doc, nil
+}
+
+// htmlMetadata reads the <title>, the document language and the author meta
+// tag of a page.
+func htmlMetadata(root *html.Node) Metadata {
+	var meta Metadata
+	if title := findElement(root, "title"); title != nil {
+		meta.Title = strings.Join(strings.Fields(nodeText(title)), " ")
+	}
+	if page := findElement(root, "html"); page != nil {
+		meta.Language = attr(page, "lang")
+	}
+	findNode(root, func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "meta" && strings.EqualFold(attr(n, "name"), "author") {
+			meta.Author = strings.TrimSpace(attr(n, "content"))
+			return true
+		}
+		return false
+	})
+	return meta
+}
+
+func findElement(root *html.Node, name string) *html.Node {
+	return findNode(root, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == name
+	})
+}
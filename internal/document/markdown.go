@@ -0,0 +1,435 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const markdownFormat = "markdown"
+
+func init() {
+	Register(markdownFormat, LoaderFunc(loadMarkdown), []string{".md", ".markdown", ".mdown", ".mkd"})
+}
+
+var (
+	atxHeadingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextRe       = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	ruleRe         = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceRe        = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	bulletItemRe   = regexp.MustCompile(`^([ \t]*)[-*+][ \t]+(.*)$`)
+	orderedItemRe  = regexp.MustCompile(`^([ \t]*)(\d{1,9})[.)][ \t]+(.*)$`)
+	quoteRe        = regexp.MustCompile(`^ {0,3}>[ ]?(.*)$`)
+	frontMatterRe  = regexp.MustCompile(`^(\w+):[ \t]*(.*)$`)
+	hardBreakRe    = regexp.MustCompile(`(?:  +|\\)$`)
+	indentedCodeRe = regexp.MustCompile(`^(?: {4}|\t)(.*)$`)
+)
+
+// markdownRenderer turns Markdown source into styled lines. Paragraphs are
+// joined into a single line, like the HTML renderer does, and markers such as
+// "#" or "**" are removed in favour of line kinds and spans.
+type markdownRenderer struct {
+	lineBuilder
+
+	chapters  []Chapter
+	paragraph []string
+	kind      LineKind // Kind of the paragraph being collected
+	listItem  bool     // The paragraph being collected is a list item
+	inList    bool     // The last block was a list item
+	metadata  Metadata
+}
+
+func loadMarkdown(src Source) (*Document, error) {
+	text, err := decodeText(src.Data, src.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &markdownRenderer{lineBuilder: newLineBuilder()}
+	r.render(splitLines(string(text)))
+
+	doc := r.document()
+	doc.Chapters = r.chapters
+	doc.Metadata = r.metadata
+	return doc, nil
+}
+
+func (r *markdownRenderer) render(lines []string) {
+	lines = r.frontMatter(lines)
+
+	var fence string
+	for i := 0; i < len(lines); i++ {
+		line := expandTabs(lines[i])
+
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				r.paragraphBreak()
+				continue
+			}
+			r.emit(line, LineCode)
+			continue
+		}
+
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			r.flush()
+			r.paragraphBreak()
+			fence = m[1]
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			r.flush()
+			r.paragraphBreak()
+			continue
+		}
+
+		// Indented code only starts after a blank line, otherwise it is a
+		// lazy continuation of the previous paragraph or list item.
+		if m := indentedCodeRe.FindStringSubmatch(line); m != nil && len(r.paragraph) == 0 && r.pendingBlank && !r.inList {
+			r.emit(m[1], LineCode)
+			continue
+		}
+
+		if m := atxHeadingRe.FindStringSubmatch(line); m != nil {
+			r.flush()
+			r.heading(m[2], len(m[1])-1)
+			continue
+		}
+
+		// Setext headings underline the paragraph collected so far, never a
+		// list item.
+		if m := setextRe.FindStringSubmatch(line); m != nil && len(r.paragraph) > 0 && r.kind == LineText && !r.listItem {
+			text := strings.Join(r.paragraph, " ")
+			r.paragraph = nil
+			level := 0
+			if m[1][0] == '-' {
+				level = 1
+			}
+			r.heading(text, level)
+			continue
+		}
+
+		if ruleRe.MatchString(line) {
+			r.flush()
+			r.paragraphBreak()
+			continue
+		}
+
+		if m := quoteRe.FindStringSubmatch(line); m != nil {
+			if r.kind != LineQuote {
+				r.flush()
+			}
+			r.kind = LineQuote
+			if strings.TrimSpace(m[1]) == "" {
+				r.flush()
+				r.kind = LineQuote
+				continue
+			}
+			r.add(m[1])
+			continue
+		}
+
+		if m := bulletItemRe.FindStringSubmatch(line); m != nil {
+			r.flush()
+			r.listItem = true
+			r.paragraph = []string{listIndent(m[1]) + "• " + m[2]}
+			continue
+		}
+		if m := orderedItemRe.FindStringSubmatch(line); m != nil {
+			r.flush()
+			r.listItem = true
+			r.paragraph = []string{listIndent(m[1]) + m[2] + ". " + m[3]}
+			continue
+		}
+
+		// Anything else continues the current paragraph, quote or list item.
+		r.add(line)
+	}
+	r.flush()
+	r.finish()
+}
+
+// add appends a source line to the current paragraph, ending the line early
+// when it carries a Markdown hard break.
+func (r *markdownRenderer) add(line string) {
+	if hardBreakRe.MatchString(line) {
+		r.paragraph = append(r.paragraph, strings.TrimRight(hardBreakRe.ReplaceAllString(line, ""), " "))
+		kind := r.kind
+		r.flushLine()
+		r.kind = kind
+		return
+	}
+	r.paragraph = append(r.paragraph, line)
+}
+
+// flush ends the current paragraph. Items of the same list are not separated
+// by blank lines.
+func (r *markdownRenderer) flush() {
+	if len(r.paragraph) > 0 {
+		r.flushLine()
+		r.inList = r.listItem
+		if !r.listItem {
+			r.paragraphBreak()
+		}
+	}
+	r.kind = LineText
+	r.listItem = false
+}
+
+func (r *markdownRenderer) flushLine() {
+	if len(r.paragraph) == 0 {
+		return
+	}
+	// Keep the leading indentation of nested list items.
+	first := r.paragraph[0]
+	indent := first[:len(first)-len(strings.TrimLeft(first, " "))]
+	for i, part := range r.paragraph {
+		r.paragraph[i] = strings.TrimSpace(part)
+	}
+	text, spans := parseInline(strings.Join(r.paragraph, " "))
+	for i := range spans {
+		spans[i].Start += len(indent)
+		spans[i].End += len(indent)
+	}
+	r.paragraph = nil
+	r.emit(indent+text, r.kind, spans...)
+}
+
+func (r *markdownRenderer) heading(source string, level int) {
+	r.inList = false
+	r.paragraphBreak()
+	text, _ := parseInline(strings.TrimSpace(source))
+	if text != "" {
+		r.chapters = append(r.chapters, Chapter{Title: text, Line: r.nextLine(), Level: level})
+		r.emit(text, LineHeading)
+	}
+	r.paragraphBreak()
+}
+
+// frontMatter strips a YAML front matter block, keeping the few keys that map
+// to document metadata.
+func (r *markdownRenderer) frontMatter(lines []string) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return lines
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" || line == "..." {
+			return lines[i+1:]
+		}
+		m := frontMatterRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(m[2]), `"'`)
+		switch strings.ToLower(m[1]) {
+		case "title":
+			r.metadata.Title = value
+		case "author":
+			r.metadata.Author = value
+		case "lang", "language":
+			r.metadata.Language = value
+		}
+	}
+	// No closing delimiter: it was a rule, not front matter.
+	r.metadata = Metadata{}
+	return lines
+}
+
+// listIndent normalizes the indentation of a list item to two spaces per
+// nesting level.
+func listIndent(indent string) string {
+	return strings.Repeat("  ", len(expandTabs(indent))/2)
+}
+
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var sb strings.Builder
+	col := 0
+	for _, c := range line {
+		if c == '\t' {
+			n := 4 - col%4
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteRune(c)
+		col++
+	}
+	return sb.String()
+}
+
+// parseInline removes the inline Markdown markup of s and returns the plain
+// text together with the spans describing its emphasis and code.
+func parseInline(s string) (string, []Span) {
+	var out strings.Builder
+	var spans []Span
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			out.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			run := delimiterRun(s, i, '`')
+			if end := strings.Index(s[i+run:], s[i:i+run]); end >= 0 {
+				code := strings.TrimSpace(s[i+run : i+run+end])
+				spans = append(spans, Span{Start: out.Len(), End: out.Len() + len(code), Style: SpanCode})
+				out.WriteString(code)
+				i += run + end + run
+				continue
+			}
+
+		case c == '!' && i+1 < len(s) && s[i+1] == '[':
+			if label, next, ok := parseLink(s, i+1); ok {
+				text, _ := parseInline(label)
+				out.WriteString(text)
+				i = next
+				continue
+			}
+
+		case c == '[':
+			if label, next, ok := parseLink(s, i); ok {
+				text, inner := parseInline(label)
+				spans = append(spans, shiftSpans(inner, out.Len())...)
+				out.WriteString(text)
+				i = next
+				continue
+			}
+
+		case c == '*' || c == '_':
+			run := min(delimiterRun(s, i, c), 3)
+			if end, ok := closingDelimiter(s, i, c, run); ok {
+				text, inner := parseInline(s[i+run : end])
+				var style SpanStyle
+				switch run {
+				case 1:
+					style = SpanEmphasis
+				case 2:
+					style = SpanStrong
+				default:
+					style = SpanStrong | SpanEmphasis
+				}
+				spans = append(spans, Span{Start: out.Len(), End: out.Len() + len(text), Style: style})
+				spans = append(spans, shiftSpans(inner, out.Len())...)
+				out.WriteString(text)
+				i = end + run
+				continue
+			}
+			// Unmatched delimiters are literal text.
+			out.WriteString(s[i : i+run])
+			i += run
+			continue
+		}
+
+		out.WriteByte(c)
+		i++
+	}
+	return out.String(), spans
+}
+
+// parseLink parses "[label](destination)" starting at the opening bracket.
+func parseLink(s string, start int) (label string, next int, ok bool) {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '(' {
+				if end := strings.IndexByte(s[i+2:], ')'); end >= 0 {
+					return s[start+1 : i], i + 2 + end + 1, true
+				}
+			}
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+// closingDelimiter finds the end of an emphasis run of length run opened at
+// start. Openers must be followed by text and closers preceded by text;
+// underscores additionally cannot open or close inside a word.
+func closingDelimiter(s string, start int, c byte, run int) (int, bool) {
+	after := start + run
+	if after >= len(s) || isSpaceAt(s, after) {
+		return 0, false
+	}
+	if c == '_' && isWordBefore(s, start) {
+		return 0, false
+	}
+	delim := strings.Repeat(string(c), run)
+	for i := after + 1; i+run <= len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '`' {
+			// Emphasis cannot close inside a code span.
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				i += end + 1
+			}
+			continue
+		}
+		if !strings.HasPrefix(s[i:], delim) || isSpaceAt(s, i-1) {
+			continue
+		}
+		// A longer run belongs to another delimiter, e.g. "**" when closing "*".
+		if delimiterRun(s, i, c) != run {
+			i += delimiterRun(s, i, c) - 1
+			continue
+		}
+		if c == '_' && i+run < len(s) && isWordAt(s, i+run) {
+			continue
+		}
+		return i, true
+	}
+	return 0, false
+}
+
+func delimiterRun(s string, start int, c byte) int {
+	n := 0
+	for start+n < len(s) && s[start+n] == c {
+		n++
+	}
+	return n
+}
+
+func shiftSpans(spans []Span, offset int) []Span {
+	for i := range spans {
+		spans[i].Start += offset
+		spans[i].End += offset
+	}
+	return spans
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func isSpaceAt(s string, i int) bool {
+	return i < 0 || i >= len(s) || s[i] == ' ' || s[i] == '\t'
+}
+
+func isWordAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWordBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
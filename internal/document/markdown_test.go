@@ -0,0 +1,127 @@
+package document
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		source string
+		text   string
+		spans  []Span
+	}{
+		{"plain text", "plain text", nil},
+		{"*em* and **strong**", "em and strong", []Span{{0, 2, SpanEmphasis}, {7, 13, SpanStrong}}},
+		{"***both***", "both", []Span{{0, 4, SpanStrong | SpanEmphasis}}},
+		{"_under_score", "_under_score", nil},
+		{"snake_case_name", "snake_case_name", nil},
+		{"use `a*b*c` here", "use a*b*c here", []Span{{4, 9, SpanCode}}},
+		{"a [link](http://x.y) b", "a link b", nil},
+		{"a [**bold** link](x)", "a bold link", []Span{{2, 6, SpanStrong}}},
+		{"![alt text](img.png)", "alt text", nil},
+		{`\*not em\*`, "*not em*", nil},
+		{"2 * 3 * 4", "2 * 3 * 4", nil},
+		{"**unclosed", "**unclosed", nil},
+	}
+	for _, tt := range tests {
+		text, spans := parseInline(tt.source)
+		if text != tt.text || !slices.Equal(spans, tt.spans) {
+			t.Errorf("parseInline(%q) = %q, %v, want %q, %v", tt.source, text, spans, tt.text, tt.spans)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		lines    []string
+		kinds    map[int]LineKind
+		chapters []Chapter
+	}{
+		{
+			name:     "headings",
+			source:   "# One #\ntext\n## Two",
+			lines:    []string{"One", "", "text", "", "Two"},
+			kinds:    map[int]LineKind{0: LineHeading, 4: LineHeading},
+			chapters: []Chapter{{"One", 0, 0}, {"Two", 4, 1}},
+		},
+		{
+			name:     "setext headings",
+			source:   "One\n===\n\nTwo\n---",
+			lines:    []string{"One", "", "Two"},
+			kinds:    map[int]LineKind{0: LineHeading, 2: LineHeading},
+			chapters: []Chapter{{"One", 0, 0}, {"Two", 2, 1}},
+		},
+		{
+			name:   "rule after a list item",
+			source: "- item\n---\ntext",
+			lines:  []string{"• item", "", "text"},
+		},
+		{
+			name:   "paragraphs",
+			source: "one\ntwo\n\n\nthree  \nfour",
+			lines:  []string{"one two", "", "three", "four"},
+		},
+		{
+			name:   "lists",
+			source: "- one\n- two\n  more\n  * nested\n\n1. first\n2) second",
+			lines:  []string{"• one", "• two more", "  • nested", "", "1. first", "2. second"},
+		},
+		{
+			name:   "quote",
+			source: "> quoted\n> text\n\nafter",
+			lines:  []string{"quoted text", "", "after"},
+			kinds:  map[int]LineKind{0: LineQuote},
+		},
+		{
+			name:   "fenced code",
+			source: "```go\nfunc f() {\n\treturn\n}\n```\ntext",
+			lines:  []string{"func f() {", "    return", "}", "", "text"},
+			kinds:  map[int]LineKind{0: LineCode, 1: LineCode, 2: LineCode},
+		},
+		{
+			name:   "indented code",
+			source: "text\n\n    code\n",
+			lines:  []string{"text", "", "code"},
+			kinds:  map[int]LineKind{2: LineCode},
+		},
+		{
+			name:   "rule",
+			source: "one\n\n***\n\ntwo",
+			lines:  []string{"one", "", "two"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := load(t, "test.md", []byte(tt.source))
+			if !slices.Equal(doc.Lines, tt.lines) {
+				t.Errorf("Lines = %q, want %q", doc.Lines, tt.lines)
+			}
+			if tt.kinds == nil {
+				tt.kinds = map[int]LineKind{}
+			}
+			if !maps.Equal(doc.Kinds, tt.kinds) {
+				t.Errorf("Kinds = %v, want %v", doc.Kinds, tt.kinds)
+			}
+			if !slices.Equal(doc.Chapters, tt.chapters) {
+				t.Errorf("Chapters = %v, want %v", doc.Chapters, tt.chapters)
+			}
+		})
+	}
+}
+
+func TestMarkdownFrontMatter(t *testing.T) {
+	source := strings.Join([]string{"---", "title: \"A Book\"", "author: Someone", "lang: es", "---", "Text"}, "\n")
+	doc := load(t, "test.md", []byte(source))
+	want := Metadata{Title: "A Book", Author: "Someone", Language: "es"}
+	if doc.Metadata != want {
+		t.Errorf("Metadata = %+v, want %+v", doc.Metadata, want)
+	}
+	if !slices.Equal(doc.Lines, []string{"Text"}) {
+		t.Errorf("Lines = %q, want %q", doc.Lines, []string{"Text"})
+	}
+}
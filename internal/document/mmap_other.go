@@ -0,0 +1,17 @@
+//go:build !unix
+
+package document
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the whole file, as there is no mmap to map it with.
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
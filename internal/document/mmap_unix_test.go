@@ -0,0 +1,55 @@
+//go:build unix
+
+package document
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	large := bytes.Repeat([]byte("line\n"), mapThreshold/5+1)
+	tests := []struct {
+		name   string
+		data   []byte
+		age    time.Duration
+		mapped bool
+	}{
+		{"small", []byte("one\ntwo\n"), time.Hour, false},
+		{"large", large, time.Hour, true},
+		{"large being written", large, 0, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modified := time.Now().Add(-tt.age)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+
+		data, release, err := readFile(path)
+		if err != nil {
+			t.Fatalf("%s: readFile: %v", tt.name, err)
+		}
+		if !bytes.Equal(data, tt.data) {
+			t.Errorf("%s: readFile returned %d bytes, want %d", tt.name, len(data), len(tt.data))
+		}
+		if err := release(); err != nil {
+			t.Errorf("%s: release: %v", tt.name, err)
+		}
+		// Only a mapping fails to be released twice.
+		if mapped := release() != nil; mapped != tt.mapped {
+			t.Errorf("%s: mapped = %v, want %v", tt.name, mapped, tt.mapped)
+		}
+	}
+
+	if _, _, err := readFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("readFile of a missing file: no error")
+	}
+}
@@ -0,0 +1,203 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const odtFormat = "odt"
+
+func init() {
+	// Like EPUB, OpenDocument stores its media type uncompressed at the start
+	// of the archive.
+	Register(odtFormat, LoaderFunc(loadODT), []string{".odt"},
+		Magic{Offset: 30, Bytes: []byte("mimetypeapplication/vnd.oasis.opendocument.text")})
+}
+
+// odtMeta is the meta.xml part of OpenDocument packages.
+type odtMeta struct {
+	Title          string `xml:"meta>title"`
+	Creator        string `xml:"meta>creator"`
+	InitialCreator string `xml:"meta>initial-creator"`
+	Language       string `xml:"meta>language"`
+}
+
+// odtRenderer walks content.xml. Paragraphs become lines, headings chapters
+// and spans whose style is bold or italic get styled.
+type odtRenderer struct {
+	officeRenderer
+
+	// Text styles by name, read from the automatic styles of the document.
+	styles    map[string]SpanStyle
+	styleName string // Style being defined
+
+	inParagraph bool
+	level       int         // Outline level of the current heading, 0 for paragraphs
+	runStyles   []SpanStyle // Styles of the nested spans, the paragraph first
+	lists       int         // Nesting depth of <text:list>
+	note        *strings.Builder
+}
+
+func loadODT(src Source) (*Document, error) {
+	z, err := openZip(src, "ODT")
+	if err != nil {
+		return nil, err
+	}
+	content, err := readZipEntry(z, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("no content.xml found in ODT")
+	}
+
+	r := &odtRenderer{
+		officeRenderer: newOfficeRenderer(),
+		styles: map[string]SpanStyle{
+			// Character styles LibreOffice ships with.
+			"Emphasis":           SpanEmphasis,
+			"Strong_20_Emphasis": SpanStrong,
+			"Source_20_Text":     SpanCode,
+		},
+	}
+	if err := r.render(content); err != nil {
+		return nil, fmt.Errorf("error parsing ODT: %v", err)
+	}
+	r.finishNotes()
+
+	doc := r.document()
+	if data, err := readZipEntry(z, "meta.xml"); err == nil && data != nil {
+		var meta odtMeta
+		if xml.Unmarshal(data, &meta) == nil {
+			author := meta.Creator
+			if author == "" {
+				author = meta.InitialCreator
+			}
+			doc.Metadata = Metadata{
+				Title:    strings.TrimSpace(meta.Title),
+				Author:   strings.TrimSpace(author),
+				Language: strings.TrimSpace(meta.Language),
+			}
+		}
+	}
+	return doc, nil
+}
+
+func (r *odtRenderer) render(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "note-citation", "annotation", "tracked-changes", "frame", "table-of-content":
+				// Note numbers are assigned by the renderer; comments, revisions,
+				// images and text boxes, and generated indexes are not text.
+				if err := decoder.Skip(); err != nil {
+					return err
+				}
+			default:
+				r.start(t)
+			}
+		case xml.EndElement:
+			r.end(t.Name.Local)
+		case xml.CharData:
+			switch {
+			case r.note != nil:
+				appendText(r.note, string(t))
+			case r.inParagraph:
+				r.write(string(t), r.runStyle())
+			}
+		}
+	}
+}
+
+func (r *odtRenderer) start(t xml.StartElement) {
+	if r.note != nil {
+		if t.Name.Local == "p" || t.Name.Local == "s" || t.Name.Local == "tab" {
+			appendText(r.note, " ")
+		}
+		return
+	}
+
+	switch t.Name.Local {
+	case "style":
+		r.styleName = xmlAttr(t, "name")
+	case "text-properties":
+		if r.styleName != "" {
+			if xmlAttr(t, "font-weight") == "bold" {
+				r.styles[r.styleName] |= SpanStrong
+			}
+			if style := xmlAttr(t, "font-style"); style == "italic" || style == "oblique" {
+				r.styles[r.styleName] |= SpanEmphasis
+			}
+		}
+	case "p", "h":
+		r.inParagraph = true
+		r.level = 0
+		if t.Name.Local == "h" {
+			r.level = 1
+			if level, err := strconv.Atoi(xmlAttr(t, "outline-level")); err == nil && level > 0 {
+				r.level = level
+			}
+		}
+		r.inline.Reset()
+		r.spans = nil
+		r.runStyles = []SpanStyle{r.styles[xmlAttr(t, "style-name")]}
+	case "span":
+		r.runStyles = append(r.runStyles, r.styles[xmlAttr(t, "style-name")])
+	case "s", "tab":
+		r.write(" ", 0)
+	case "line-break":
+		r.lineBreak(r.level)
+	case "list":
+		r.lists++
+	case "list-item":
+		r.prefix = listBullet(max(0, r.lists-1))
+	case "note":
+		r.note = &strings.Builder{}
+	}
+}
+
+func (r *odtRenderer) end(name string) {
+	if r.note != nil {
+		if name == "note" {
+			r.noteRef(r.note.String())
+			r.note = nil
+		}
+		return
+	}
+
+	switch name {
+	case "style":
+		r.styleName = ""
+	case "span":
+		if n := len(r.runStyles); n > 1 {
+			r.runStyles = r.runStyles[:n-1]
+		}
+	case "p", "h":
+		r.inParagraph = false
+		r.flush(r.level)
+	case "list":
+		r.lists--
+	}
+}
+
+func (r *odtRenderer) runStyle() SpanStyle {
+	var style SpanStyle
+	for _, s := range r.runStyles {
+		style |= s
+	}
+	return style
+}
@@ -0,0 +1,184 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Title of the section listing the footnotes of DOCX and ODT documents.
+const footnotesTitle = "Notas al pie"
+
+// officeRenderer holds what the DOCX and ODT loaders share: paragraphs built
+// from styled runs, headings recorded as chapters and footnotes collected to
+// be listed at the end of the document.
+type officeRenderer struct {
+	lineBuilder
+
+	chapters []Chapter
+	notes    []string
+
+	inline strings.Builder
+	spans  []Span
+	prefix string // List bullet of the paragraph being built
+	inList bool   // Last paragraph was a list item
+}
+
+func newOfficeRenderer() officeRenderer {
+	return officeRenderer{lineBuilder: newLineBuilder()}
+}
+
+// write adds the text of a run to the current paragraph.
+func (r *officeRenderer) write(text string, style SpanStyle) {
+	start := r.inline.Len()
+	appendText(&r.inline, text)
+	if style != 0 && r.inline.Len() > start {
+		r.spans = append(r.spans, Span{Start: start, End: r.inline.Len(), Style: style})
+	}
+}
+
+// noteRef adds a footnote to the document and its reference to the current
+// paragraph.
+func (r *officeRenderer) noteRef(note string) {
+	r.notes = append(r.notes, strings.Join(strings.Fields(note), " "))
+	r.inline.WriteString(fmt.Sprintf("[%d]", len(r.notes)))
+}
+
+// lineBreak ends the current line without ending the paragraph.
+func (r *officeRenderer) lineBreak(level int) {
+	r.emitInline(level)
+}
+
+// flush ends the current paragraph, a heading when level is above zero. List
+// items are not separated by blank lines.
+func (r *officeRenderer) flush(level int) {
+	r.emitInline(level)
+	r.inList = r.prefix != ""
+	r.prefix = ""
+	if !r.inList {
+		r.paragraphBreak()
+	}
+}
+
+func (r *officeRenderer) emitInline(level int) {
+	line := strings.TrimRight(r.inline.String(), " ")
+	spans := r.spans
+	r.inline.Reset()
+	r.spans = nil
+	if line == "" {
+		return
+	}
+	for i := range spans {
+		spans[i].Start = min(spans[i].Start, len(line))
+		spans[i].End = min(spans[i].End, len(line))
+	}
+
+	if r.inList && r.prefix == "" {
+		r.paragraphBreak()
+		r.inList = false
+	}
+	if level == 0 {
+		// The bullet goes on the first line of a list item only.
+		if r.prefix != "" {
+			for i := range spans {
+				spans[i].Start += len(r.prefix)
+				spans[i].End += len(r.prefix)
+			}
+			line = r.prefix + line
+			r.prefix = strings.Repeat(" ", len([]rune(r.prefix)))
+		}
+		r.emit(line, LineText, spans...)
+		return
+	}
+
+	// Like in HTML, the three top heading levels make the table of contents.
+	if level <= 3 {
+		r.chapters = append(r.chapters, Chapter{Title: line, Line: r.nextLine(), Level: level - 1})
+	}
+	r.emit(line, LineHeading, spans...)
+}
+
+// finishNotes lists the footnotes referenced by the document under a heading
+// of their own.
+func (r *officeRenderer) finishNotes() {
+	if len(r.notes) > 0 {
+		r.paragraphBreak()
+		r.chapters = append(r.chapters, Chapter{Title: footnotesTitle, Line: r.nextLine()})
+		r.emit(footnotesTitle, LineHeading)
+		for i, note := range r.notes {
+			r.paragraphBreak()
+			r.emit(fmt.Sprintf("[%d] %s", i+1, note), LineText)
+		}
+	}
+	r.finish()
+}
+
+func (r *officeRenderer) document() *Document {
+	doc := r.lineBuilder.document()
+	doc.Chapters = r.chapters
+	return doc
+}
+
+// listBullet is the prefix of an item of a list nested depth levels deep,
+// counting from zero.
+func listBullet(depth int) string {
+	return strings.Repeat("  ", depth) + "• "
+}
+
+// openZip opens the zip container of an office document.
+func openZip(src Source, format string) (*zip.Reader, error) {
+	z, err := zip.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", format, err)
+	}
+	return z, nil
+}
+
+// readZipEntry returns the contents of the named file of z, or nil if there is
+// no such file.
+func readZipEntry(z *zip.Reader, name string) ([]byte, error) {
+	for _, f := range z.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s: %v", name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", name, err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// xmlAttr returns the value of the attribute of t with the given local name,
+// whatever its namespace.
+func xmlAttr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// xmlBool parses an on/off attribute value. Office formats write toggles as
+// an element with an optional val attribute, so a missing value means on.
+func xmlBool(val string) bool {
+	if val == "" {
+		return true
+	}
+	on, err := strconv.ParseBool(val)
+	if err != nil {
+		return val == "on"
+	}
+	return on
+}
@@ -0,0 +1,83 @@
+package document
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+const testDOCX = `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Chapter One</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">Some </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>bold</w:t></w:r><w:r><w:t xml:space="preserve"> and </w:t></w:r><w:r><w:rPr><w:i/></w:rPr><w:t>italic</w:t></w:r><w:r><w:footnoteReference w:id="1"/></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/></w:numPr></w:pPr><w:r><w:t>First</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/></w:numPr></w:pPr><w:r><w:t>Nested</w:t></w:r></w:p>
+<w:p><w:r><w:t>After</w:t><w:br/><w:t>the list</w:t></w:r><w:del><w:r><w:t>gone</w:t></w:r></w:del></w:p>
+</w:body></w:document>`
+
+const testDOCXFootnotes = `<w:footnotes xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:footnote w:id="1"><w:p><w:r><w:t>A note.</w:t></w:r></w:p></w:footnote>
+</w:footnotes>`
+
+const testDOCXCore = `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>The Title</dc:title><dc:creator>The Author</dc:creator><dc:language>en</dc:language>
+</cp:coreProperties>`
+
+const testODT = `<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0">
+<office:automatic-styles><style:style style:name="T1"><style:text-properties fo:font-weight="bold"/></style:style></office:automatic-styles>
+<office:body><office:text>
+<text:h text:outline-level="1">Chapter One</text:h>
+<text:p>Some <text:span text:style-name="T1">bold</text:span> and <text:span text:style-name="Emphasis">italic</text:span><text:note><text:note-citation>1</text:note-citation><text:note-body><text:p>A note.</text:p></text:note-body></text:note></text:p>
+<text:list><text:list-item><text:p>First</text:p><text:list><text:list-item><text:p>Nested</text:p></text:list-item></text:list></text:list-item></text:list>
+<text:p>After<text:line-break/>the list</text:p>
+</office:text></office:body></office:document-content>`
+
+const testODTMeta = `<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<office:meta><dc:title>The Title</dc:title><meta:initial-creator>The Author</meta:initial-creator><dc:language>en</dc:language></office:meta>
+</office:document-meta>`
+
+func TestOffice(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"test.docx", zipFiles(t,
+			"word/document.xml", testDOCX,
+			"word/footnotes.xml", testDOCXFootnotes,
+			"docProps/core.xml", testDOCXCore)},
+		{"test.odt", zipFiles(t,
+			"mimetype", "application/vnd.oasis.opendocument.text",
+			"content.xml", testODT,
+			"meta.xml", testODTMeta)},
+	}
+
+	// Both formats render the same document.
+	lines := []string{
+		"Chapter One", "", "Some bold and italic[1]", "", "• First", "  • Nested", "", "After", "the list",
+		"", footnotesTitle, "", "[1] A note.",
+	}
+	kinds := map[int]LineKind{0: LineHeading, 10: LineHeading}
+	spans := []Span{{5, 9, SpanStrong}, {14, 20, SpanEmphasis}}
+	chapters := []Chapter{{"Chapter One", 0, 0}, {footnotesTitle, 10, 0}}
+	metadata := Metadata{Title: "The Title", Author: "The Author", Language: "en"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := load(t, tt.name, tt.data)
+			if !slices.Equal(doc.Lines, lines) {
+				t.Errorf("Lines = %q, want %q", doc.Lines, lines)
+			}
+			if !maps.Equal(doc.Kinds, kinds) {
+				t.Errorf("Kinds = %v, want %v", doc.Kinds, kinds)
+			}
+			if !slices.Equal(doc.Spans[2], spans) {
+				t.Errorf("Spans = %v, want %v", doc.Spans[2], spans)
+			}
+			if !slices.Equal(doc.Chapters, chapters) {
+				t.Errorf("Chapters = %v, want %v", doc.Chapters, chapters)
+			}
+			if doc.Metadata != metadata {
+				t.Errorf("Metadata = %+v, want %+v", doc.Metadata, metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package document
+
+import (
+	"strings"
+	"unsafe"
+)
+
+const plainTextFormat = "text"
+
+var plainTextLoader = LoaderFunc(loadPlainText)
+
+func init() {
+	Register(plainTextFormat, plainTextLoader, []string{".txt", ".text"})
+}
+
+func loadPlainText(src Source) (*Document, error) {
+	text, err := decodeText(src.Data, src.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Lines: splitLines(bytesToString(text))}, nil
+}
+
+// bytesToString returns data as a string without copying it, so the lines of
+// a large file point into its mapping. Loaders never modify their input, which
+// keeps the string immutable as required.
+func bytesToString(data []byte) string {
+	return unsafe.String(unsafe.SliceData(data), len(data))
+}
+
+// splitLines splits text on newlines, accepting CRLF endings and ignoring the
+// terminating newline of the last line.
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
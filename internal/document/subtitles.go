@@ -0,0 +1,165 @@
+package document
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const subtitlesFormat = "subtitles"
+
+func init() {
+	Register(subtitlesFormat, LoaderFunc(loadSubtitles), []string{".srt", ".vtt"},
+		Magic{Bytes: []byte("WEBVTT")},
+		Magic{Bytes: []byte("\xef\xbb\xbfWEBVTT")})
+}
+
+var (
+	cueTimingRe = regexp.MustCompile(`^(\S+)\s+-->\s+(\S+)`)
+	// Subtitle files are often named after their language: "movie.es.srt".
+	subtitleLanguageRe = regexp.MustCompile(`^\.([a-z]{2,3}([-_][a-z]{2,4})?)$`)
+)
+
+// loadSubtitles reads SubRip (.srt) and WebVTT (.vtt) files. Every line of
+// text of a cue becomes a line of the document, and the cue keeps the timing.
+// Cue numbers, identifiers, and NOTE and STYLE blocks are dropped. Files
+// without cues yet, such as empty ones, load as empty documents.
+func loadSubtitles(src Source) (*Document, error) {
+	data, err := decodeText(src.Data, src.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newLineBuilder()
+	var cues []Cue
+	lines := splitLines(strings.TrimPrefix(string(data), "\ufeff"))
+	for i := 0; i < len(lines); i++ {
+		m := cueTimingRe.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if m == nil {
+			continue
+		}
+		start, err := ParseTimestamp(m[1])
+		if err != nil {
+			continue
+		}
+		end, err := ParseTimestamp(m[2])
+		if err != nil {
+			continue
+		}
+
+		cue := Cue{Line: b.nextLine(), Start: start, End: end}
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			i++
+			if text, spans := parseCueText(lines[i]); text != "" {
+				b.emit(text, LineText, spans...)
+			}
+		}
+		if b.nextLine() > cue.Line {
+			cues = append(cues, cue)
+		}
+	}
+	doc := b.document()
+	doc.Cues = cues
+	base := strings.ToLower(trimExtension(path.Base(src.Name), ".srt", ".vtt"))
+	if m := subtitleLanguageRe.FindStringSubmatch(path.Ext(base)); m != nil {
+		doc.Metadata.Language = m[1]
+	}
+	return doc, nil
+}
+
+// parseCueText strips the markup of a line of cue text. Italic and bold tags
+// become spans, WebVTT voices a "Name: " prefix, and SubRip position codes
+// such as {\an8} are dropped.
+func parseCueText(line string) (string, []Span) {
+	var sb strings.Builder
+	var spans, open []Span
+	for line != "" {
+		switch {
+		case line[0] == '<':
+			end := strings.IndexByte(line, '>')
+			if end < 0 {
+				appendText(&sb, html.UnescapeString(line))
+				line = ""
+				continue
+			}
+			raw := strings.TrimSpace(line[1:end])
+			tag := strings.ToLower(raw)
+			line = line[end+1:]
+			switch {
+			case tag == "i" || tag == "b" || strings.HasPrefix(tag, "i.") || strings.HasPrefix(tag, "b."):
+				style := SpanEmphasis
+				if tag[0] == 'b' {
+					style = SpanStrong
+				}
+				open = append(open, Span{Start: sb.Len(), Style: style})
+			case tag == "/i" || tag == "/b":
+				if n := len(open); n > 0 {
+					span := open[n-1]
+					open = open[:n-1]
+					span.End = sb.Len()
+					if span.End > span.Start {
+						spans = append(spans, span)
+					}
+				}
+			case strings.HasPrefix(tag, "v ") || strings.HasPrefix(tag, "v."):
+				if _, name, ok := strings.Cut(raw, " "); ok {
+					appendText(&sb, html.UnescapeString(strings.TrimSpace(name))+": ")
+				}
+			}
+		case strings.HasPrefix(line, "{\\"):
+			end := strings.IndexByte(line, '}')
+			if end < 0 {
+				end = len(line) - 1
+			}
+			line = line[end+1:]
+		default:
+			end := strings.IndexAny(line[1:], "<{")
+			if end < 0 {
+				end = len(line) - 1
+			}
+			appendText(&sb, html.UnescapeString(line[:end+1]))
+			line = line[end+1:]
+		}
+	}
+
+	text := strings.TrimRight(sb.String(), " ")
+	for i := range spans {
+		spans[i].End = min(spans[i].End, len(text))
+	}
+	return text, spans
+}
+
+// ParseTimestamp parses a subtitle timestamp, "hh:mm:ss,mmm" in SubRip and
+// "hh:mm:ss.mmm" in WebVTT. Hours and milliseconds may be left out, as in
+// "02:03" or "1:02:03".
+func ParseTimestamp(s string) (time.Duration, error) {
+	clock, fraction := s, ""
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		clock, fraction = s[:i], s[i+1:]
+	}
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 || len(fraction) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var d time.Duration
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		d = d*60 + time.Duration(n)*time.Second
+	}
+	if fraction != "" {
+		ms, err := strconv.Atoi(fraction + strings.Repeat("0", 3-len(fraction)))
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		d += time.Duration(ms) * time.Millisecond
+	}
+	return d, nil
+}
@@ -0,0 +1,99 @@
+package document
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Duration
+		ok   bool
+	}{
+		{"00:00:01,500", 1500 * time.Millisecond, true},
+		{"01:02:03.004", time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, true},
+		{"02:03", 2*time.Minute + 3*time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"00:00:01.5", 1500 * time.Millisecond, true},
+		{"00:60:00", 0, false},
+		{"12", 0, false},
+		{"aa:bb", 0, false},
+		{"00:00:01.0001", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.s)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v, ok %v", tt.s, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseCueText(t *testing.T) {
+	tests := []struct {
+		line  string
+		text  string
+		spans []Span
+	}{
+		{"Hello", "Hello", nil},
+		{"<i>Hello</i> there", "Hello there", []Span{{0, 5, SpanEmphasis}}},
+		{"<b.loud>Stop</b>", "Stop", []Span{{0, 4, SpanStrong}}},
+		{"<v Mary>Hi", "Mary: Hi", nil},
+		{"{\\an8}Top", "Top", nil},
+		{"Tom &amp; Jerry", "Tom & Jerry", nil},
+		{"<font color=\"red\">Red</font>", "Red", nil},
+	}
+	for _, tt := range tests {
+		text, spans := parseCueText(tt.line)
+		if text != tt.text || !slices.Equal(spans, tt.spans) {
+			t.Errorf("parseCueText(%q) = %q, %v, want %q, %v", tt.line, text, spans, tt.text, tt.spans)
+		}
+	}
+}
+
+func TestSubtitles(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		lines []string
+		cues  []Cue
+		lang  string
+	}{
+		{
+			name:  "movie.es.srt",
+			data:  "1\r\n00:00:01,000 --> 00:00:02,500\r\nHola\r\n<i>amigo</i>\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nAdiós\r\n",
+			lines: []string{"Hola", "amigo", "Adiós"},
+			cues: []Cue{
+				{Line: 0, Start: time.Second, End: 2500 * time.Millisecond},
+				{Line: 2, Start: 3 * time.Second, End: 4 * time.Second},
+			},
+			lang: "es",
+		},
+		{
+			name:  "talk.vtt",
+			data:  "\ufeffWEBVTT\n\nNOTE a comment\n\nintro\n00:01.000 --> 00:02.000 align:start\n<v Ann>Hi\n\n00:02.000 --> 00:03.000\n\n00:03.000 --> 00:04.000\nBye\n",
+			lines: []string{"Ann: Hi", "Bye"},
+			cues: []Cue{
+				{Line: 0, Start: time.Second, End: 2 * time.Second},
+				{Line: 1, Start: 3 * time.Second, End: 4 * time.Second},
+			},
+		},
+		{name: "empty.srt", data: "", lines: []string{""}},
+		{name: "notes.vtt", data: "WEBVTT\n\nNOTE nothing yet\n", lines: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := load(t, tt.name, []byte(tt.data))
+			if !slices.Equal(doc.Lines, tt.lines) {
+				t.Errorf("Lines = %q, want %q", doc.Lines, tt.lines)
+			}
+			if !slices.Equal(doc.Cues, tt.cues) {
+				t.Errorf("Cues = %v, want %v", doc.Cues, tt.cues)
+			}
+			if doc.Metadata.Language != tt.lang {
+				t.Errorf("Language = %q, want %q", doc.Metadata.Language, tt.lang)
+			}
+		})
+	}
+}
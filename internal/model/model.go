@@ -2,11 +2,16 @@ package model
 
 type ProgressEntry struct {
 	FileName       string   `json:"file_name"`
+	Title          string   `json:"title,omitempty"`
+	Author         string   `json:"author,omitempty"`
 	Line           int      `json:"line"`
 	Vocabulary     []string `json:"vocabulary"`
 	Notes          []string `json:"notes"`
 	ReadingSeconds float64  `json:"reading_seconds"`
 	ReadWords      int      `json:"read_words"`
+	Gutenberg      bool     `json:"gutenberg,omitempty"`
+	TextWidth      int      `json:"text_width,omitempty"`
+	Encoding       string   `json:"encoding,omitempty"`
 }
 
 type ProgressMap map[string]ProgressEntry
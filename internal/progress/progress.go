@@ -9,7 +9,9 @@ import (
 	"txtreader/internal/utils"
 )
 
-func Save(filePath string, line int, vocabulary, notes []string, readingSeconds float64, readWords int) error {
+// Save stores entry under key, the logical document identifier. The entry's
+// FileName is the path the document can be reopened from.
+func Save(key string, entry model.ProgressEntry) error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("error getting home directory: %v", err)
@@ -37,15 +39,8 @@ func Save(filePath string, line int, vocabulary, notes []string, readingSeconds
 	}
 
 	// Update progress entry
-	hash := utils.HashPath(filePath)
-	progress[hash] = model.ProgressEntry{
-		FileName:       filePath,
-		Line:           line,
-		Vocabulary:     vocabulary,
-		Notes:          notes,
-		ReadingSeconds: readingSeconds,
-		ReadWords:      readWords,
-	}
+	hash := utils.HashPath(key)
+	progress[hash] = entry
 
 	// Write back to file
 	data, err = json.MarshalIndent(progress, "", "    ")
@@ -58,29 +53,40 @@ func Save(filePath string, line int, vocabulary, notes []string, readingSeconds
 	return nil
 }
 
-func Load(filePath string) (int, []string, []string, float64, int, error) {
+// Load returns the progress stored under key, or an empty entry if none.
+func Load(key string) (model.ProgressEntry, error) {
+	textProgress, err := All()
+	if err != nil {
+		return model.ProgressEntry{}, err
+	}
+
+	hash := utils.HashPath(key)
+	if entry, exists := textProgress[hash]; exists {
+		return entry, nil
+	}
+	return model.ProgressEntry{}, nil // No entry for this file
+}
+
+// All returns the progress of every document opened so far, keyed by the hash
+// of their identifier.
+func All() (model.ProgressMap, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return 0, nil, nil, 0, 0, fmt.Errorf("error getting home directory: %v", err)
+		return nil, fmt.Errorf("error getting home directory: %v", err)
 	}
 	progressPath := filepath.Join(homeDir, "ltbr", "progress.json")
 
 	data, err := os.ReadFile(progressPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, nil, nil, 0, 0, nil // No progress file exists, return default values
+			return model.ProgressMap{}, nil // No progress file exists, nothing opened yet
 		}
-		return 0, nil, nil, 0, 0, fmt.Errorf("error reading progress file: %v", err)
+		return nil, fmt.Errorf("error reading progress file: %v", err)
 	}
 
 	var textProgress model.ProgressMap
 	if err := json.Unmarshal(data, &textProgress); err != nil {
-		return 0, nil, nil, 0, 0, fmt.Errorf("error parsing progress file: %v", err)
-	}
-
-	hash := utils.HashPath(filePath)
-	if entry, exists := textProgress[hash]; exists {
-		return entry.Line, entry.Vocabulary, entry.Notes, entry.ReadingSeconds, entry.ReadWords, nil
+		return nil, fmt.Errorf("error parsing progress file: %v", err)
 	}
-	return 0, nil, nil, 0, 0, nil // No entry for this file
+	return textProgress, nil
 }
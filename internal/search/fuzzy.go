@@ -0,0 +1,165 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxDistance is the number of edits a fuzzy match may need when
+// Options.MaxDistance is not set.
+const DefaultMaxDistance = 2
+
+// fuzzyEnd is the best approximate match of the term ending at a point of a
+// line: bytes start to end, dist edits away from the term.
+type fuzzyEnd struct {
+	start, end int
+	dist       int
+}
+
+// maxDistance returns the number of edits fuzzy matches may need. It is never
+// more than half the length of the term, or short terms would match almost
+// anything.
+func (m *Matcher) maxDistance() int {
+	k := m.opts.MaxDistance
+	if k <= 0 {
+		k = DefaultMaxDistance
+	}
+	return min(k, len(m.pattern)/2)
+}
+
+// fuzzyEnds computes, for every rune of s, the fewest edits (insertions,
+// deletions and substitutions) that turn some substring of s ending at that
+// rune into the term, using Sellers' variant of the edit distance. Only the
+// ends within the maximum distance are returned, in order, and in whole word
+// searches only those where the substring is a word on its own. Substrings
+// then only start at the beginning of a word; otherwise the best start for an
+// end could fall inside a word while a worse one made it a whole word. With
+// first set it stops at the first one.
+func (m *Matcher) fuzzyEnds(s string, first bool) []fuzzyEnd {
+	p := m.pattern
+	n := len(p)
+	if n == 0 || !m.mayMatch(s) {
+		return nil
+	}
+	k := m.maxDistance()
+	far := k + 1 // Any distance over k, they are all as bad
+
+	// Column of the dynamic programming table for the text read so far:
+	// dist[i] is the distance between p[:i] and the best substring ending
+	// here, which starts at byte start[i]. Only the rows up to top, the last
+	// within k, can lead to a match, so the rest are not computed (Ukkonen's
+	// cutoff) and left at far.
+	dist := make([]int, n+1)
+	start := make([]int, n+1)
+	next := make([]int, n+1)
+	nextStart := make([]int, n+1)
+	for i := range dist {
+		dist[i] = min(i, far)
+		next[i] = far
+	}
+	top := min(k, n)
+	filled := 0 // Rows of next not at far
+
+	var ends []fuzzyEnd
+	for j, r := range s {
+		after := j + utf8.RuneLen(r)
+		if r == utf8.RuneError {
+			after = j + 1
+		}
+		last := min(n, top+1)
+		next[0], nextStart[0] = 0, after
+		if m.opts.WholeWord && isWordRune(r) {
+			// Inside a word the match keeps its start, skipping r
+			next[0], nextStart[0] = min(dist[0]+1, far), start[0]
+		}
+		for i := 1; i <= last; i++ {
+			cost := 1
+			if p[i-1] == r {
+				cost = 0
+			}
+			d, st := dist[i-1]+cost, start[i-1] // Substitution or match
+			if dist[i]+1 < d {
+				d, st = dist[i]+1, start[i] // Extra rune in the text
+			}
+			if next[i-1]+1 < d {
+				d, st = next[i-1]+1, nextStart[i-1] // Rune missing from the text
+			}
+			next[i], nextStart[i] = min(d, far), st
+		}
+		for i := last + 1; i <= filled; i++ {
+			next[i] = far
+		}
+		filled = last
+		for top = last; top > 0 && next[top] > k; top-- {
+		}
+		dist, next = next, dist
+		start, nextStart = nextStart, start
+
+		if top == n && start[n] < after && (!m.opts.WholeWord || isWord(s, start[n], after)) {
+			ends = append(ends, fuzzyEnd{start: start[n], end: after, dist: dist[n]})
+			if first {
+				break
+			}
+		}
+	}
+	return ends
+}
+
+// mayMatch reports whether s may have a fuzzy match of the term. Split in one
+// more piece than the edits allowed, at least one piece of the term must be in
+// the match unchanged, and looking for them is much faster than computing
+// distances.
+func (m *Matcher) mayMatch(s string) bool {
+	for _, piece := range m.pieces {
+		if strings.Contains(s, piece) {
+			return true
+		}
+	}
+	return len(m.pieces) == 0
+}
+
+// splitPattern cuts the term of a fuzzy search into the pieces mayMatch
+// looks for.
+func (m *Matcher) splitPattern() []string {
+	parts := m.maxDistance() + 1
+	if len(m.pattern) < parts {
+		return nil
+	}
+	pieces := make([]string, 0, parts)
+	for i := range parts {
+		from, to := i*len(m.pattern)/parts, (i+1)*len(m.pattern)/parts
+		pieces = append(pieces, string(m.pattern[from:to]))
+	}
+	return pieces
+}
+
+// fuzzyFind returns the approximate matches of the term in s, a line already
+// folded. Overlapping candidates are reduced to the closest one, the longest
+// of those equally close.
+func (m *Matcher) fuzzyFind(s string, n int) [][2]int {
+	var matches [][2]int
+	ends := m.fuzzyEnds(s, n == 1)
+	for i := 0; i < len(ends) && len(matches) != n; {
+		best := ends[i]
+		for i++; i < len(ends) && ends[i].start < best.end; i++ {
+			if ends[i].dist <= best.dist {
+				best = ends[i]
+			}
+		}
+		matches = append(matches, [2]int{best.start, best.end})
+	}
+	return matches
+}
+
+// Distance returns the fewest edits that turn a part of line into the term,
+// and whether that is within the maximum distance of a fuzzy search. Lines
+// closer to the term are better matches.
+func (m *Matcher) Distance(line string) (int, bool) {
+	best := -1
+	for _, end := range m.fuzzyEnds(m.fold(line, nil), false) {
+		if best < 0 || end.dist < best {
+			best = end.dist
+		}
+	}
+	return best, best >= 0
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzy(t *testing.T) {
+	tests := []struct {
+		name     string
+		term     string
+		opts     Options
+		line     string
+		match    bool
+		matches  [][2]int
+		distance int
+	}{
+		{"exact", "accion", Options{}, "la accion sigue", true, [][2]int{{3, 9}}, 0},
+		{"typo", "accion", Options{}, "la accoin sigue", true, [][2]int{{3, 9}}, 2},
+		{"too far", "accion", Options{MaxDistance: 1}, "la acoin sigue", false, nil, -1},
+		{"case folded", "ACCION", Options{}, "la Accion", true, [][2]int{{3, 9}}, 0},
+		{"part of a word", "cat", Options{}, "concatenate", true, [][2]int{{3, 6}, {7, 10}}, 0},
+		{"whole word after a part", "cat", Options{WholeWord: true}, "concatenate cat", true, [][2]int{{12, 15}}, 0},
+		{"whole word typo", "house", Options{WholeWord: true}, "the lighthouse keeper saw a hous", true, [][2]int{{28, 32}}, 1},
+		{"whole word only parts", "cat", Options{WholeWord: true}, "concatenate", false, nil, -1},
+		{"whole word leading typo", "abcd", Options{WholeWord: true}, "zabcd", true, [][2]int{{0, 5}}, 1},
+		{"whole word leading typo then word", "abcd", Options{WholeWord: true}, "zabcd efg", true, [][2]int{{0, 5}}, 1},
+		{"short term", "ab", Options{}, "xy", false, nil, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Fuzzy = true
+			m, err := New(tt.term, tt.opts)
+			if err != nil {
+				t.Fatalf("New(%q): %v", tt.term, err)
+			}
+			if got := m.Match(tt.line); got != tt.match {
+				t.Errorf("Match(%q) = %v, want %v", tt.line, got, tt.match)
+			}
+			if got := m.FindAll(tt.line); !slices.Equal(got, tt.matches) {
+				t.Errorf("FindAll(%q) = %v, want %v", tt.line, got, tt.matches)
+			}
+			dist, ok := m.Distance(tt.line)
+			if ok != tt.match || (ok && dist != tt.distance) {
+				t.Errorf("Distance(%q) = %d, %v, want %d, %v", tt.line, dist, ok, tt.distance, tt.match)
+			}
+		})
+	}
+}
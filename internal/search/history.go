@@ -0,0 +1,73 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// maxHistory is the number of terms kept in the search history.
+const maxHistory = 100
+
+func historyPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	return filepath.Join(homeDir, "ltbr", "search_history.json"), nil
+}
+
+// LoadHistory returns the terms searched for in earlier sessions, oldest
+// first, or nil if there are none.
+func LoadHistory() ([]string, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading search history: %v", err)
+	}
+
+	var history []string
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("error parsing search history: %v", err)
+	}
+	return history, nil
+}
+
+// SaveHistory stores the search history next to the progress file.
+func SaveHistory(history []string) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating progress directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(history, "", "    ")
+	if err != nil {
+		return fmt.Errorf("error marshaling search history: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing search history: %v", err)
+	}
+	return nil
+}
+
+// AddToHistory appends term to history, moving it to the end if it was
+// already there, and drops the oldest terms past the limit.
+func AddToHistory(history []string, term string) []string {
+	history = slices.DeleteFunc(slices.Clone(history), func(t string) bool { return t == term })
+	history = append(history, term)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	return history
+}
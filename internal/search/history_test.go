@@ -0,0 +1,52 @@
+package search
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestAddToHistory(t *testing.T) {
+	tests := []struct {
+		history []string
+		term    string
+		want    []string
+	}{
+		{nil, "a", []string{"a"}},
+		{[]string{"a", "b"}, "c", []string{"a", "b", "c"}},
+		{[]string{"a", "b", "c"}, "a", []string{"b", "c", "a"}},
+		{[]string{"a"}, "a", []string{"a"}},
+	}
+	for _, tt := range tests {
+		original := slices.Clone(tt.history)
+		if got := AddToHistory(tt.history, tt.term); !slices.Equal(got, tt.want) {
+			t.Errorf("AddToHistory(%q, %q) = %q, want %q", tt.history, tt.term, got, tt.want)
+		}
+		if !slices.Equal(tt.history, original) {
+			t.Errorf("AddToHistory modified its argument: %q", tt.history)
+		}
+	}
+
+	var history []string
+	for i := range maxHistory + 10 {
+		history = AddToHistory(history, fmt.Sprint(i))
+	}
+	if len(history) != maxHistory || history[0] != "10" {
+		t.Errorf("history kept %d terms starting at %q, want %d starting at %q", len(history), history[0], maxHistory, "10")
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if history, err := LoadHistory(); history != nil || err != nil {
+		t.Fatalf("LoadHistory without a file = %q, %v, want nil, nil", history, err)
+	}
+	want := []string{"uno", "acción"}
+	if err := SaveHistory(want); err != nil {
+		t.Fatalf("SaveHistory: %v", err)
+	}
+	if got, err := LoadHistory(); err != nil || !slices.Equal(got, want) {
+		t.Errorf("LoadHistory = %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,245 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Options select how a search term is matched against the lines of a
+// document.
+type Options struct {
+	Regex         bool // The term is a regular expression, in Go's syntax
+	CaseSensitive bool // Upper and lower case letters are told apart
+	IgnoreAccents bool // "accion" matches "acción" and the other way around
+	WholeWord     bool // Matches may not be part of a longer word
+	Fuzzy         bool // Matches may differ from the term, as with typos
+	MaxDistance   int  // Edits a fuzzy match may need, DefaultMaxDistance if 0
+}
+
+// Match is an occurrence of a search term, bytes Start to End of a line of a
+// document.
+type Match struct {
+	Line       int
+	Start, End int
+}
+
+// Matcher finds a search term in lines of text.
+type Matcher struct {
+	term    string
+	opts    Options
+	folded  string   // Term with case and accents folded, for plain searches
+	pattern []rune   // Runes of folded, for fuzzy searches
+	pieces  []string // Parts of pattern a fuzzy match must contain one of
+	re      *regexp.Regexp
+}
+
+// New returns a Matcher for term. It fails if term is not a valid regular
+// expression in regex mode. Fuzzy searches take the term as plain text, even
+// if Regex is set.
+func New(term string, opts Options) (*Matcher, error) {
+	if opts.Fuzzy {
+		opts.Regex = false
+	}
+	m := &Matcher{term: term, opts: opts}
+	if !opts.Regex {
+		m.folded = m.fold(term, nil)
+		if opts.Fuzzy {
+			m.pattern = []rune(m.folded)
+			m.pieces = m.splitPattern()
+		}
+		return m, nil
+	}
+
+	// Parse the pattern alone so errors quote it as typed.
+	if _, err := syntax.Parse(term, syntax.Perl); err != nil {
+		if e, ok := err.(*syntax.Error); ok {
+			return nil, fmt.Errorf("%s: `%s`", e.Code, e.Expr)
+		}
+		return nil, err
+	}
+	pattern := m.fold(term, nil)
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	m.re = re
+	return m, nil
+}
+
+// Term returns the term searched for, as typed.
+func (m *Matcher) Term() string {
+	return m.term
+}
+
+// Options returns the options the matcher was created with.
+func (m *Matcher) Options() Options {
+	return m.opts
+}
+
+// Match reports whether line contains the term.
+func (m *Matcher) Match(line string) bool {
+	return len(m.find(m.fold(line, nil), 1)) > 0
+}
+
+// FindAll returns the byte ranges of line the term occurs at, in order.
+func (m *Matcher) FindAll(line string) [][2]int {
+	var offsets []int
+	matches := m.find(m.fold(line, &offsets), -1)
+	if offsets != nil {
+		for i := range matches {
+			matches[i] = [2]int{offsets[matches[i][0]], offsets[matches[i][1]]}
+		}
+	}
+	return matches
+}
+
+// Literal returns the lowercase text every matching line contains, for
+// lookups in a word index. ok is false when there is no such text, as with
+// regular expressions, fuzzy searches or when accents are ignored.
+func (m *Matcher) Literal() (term string, ok bool) {
+	if m.re != nil || m.opts.Fuzzy || m.opts.IgnoreAccents {
+		return "", false
+	}
+	return strings.ToLower(m.term), true
+}
+
+// find returns the byte ranges of up to n matches in s, a line already
+// folded. n < 0 returns them all.
+func (m *Matcher) find(s string, n int) [][2]int {
+	if m.opts.Fuzzy {
+		return m.fuzzyFind(s, n)
+	}
+
+	var matches [][2]int
+	add := func(start, end int) bool {
+		if m.opts.WholeWord && !isWord(s, start, end) {
+			return false
+		}
+		matches = append(matches, [2]int{start, end})
+		return true
+	}
+
+	if m.re != nil {
+		for _, loc := range m.re.FindAllStringIndex(s, -1) {
+			if loc[1] > loc[0] && add(loc[0], loc[1]) && len(matches) == n {
+				break
+			}
+		}
+		return matches
+	}
+
+	if m.folded == "" {
+		return nil
+	}
+	for i := 0; len(matches) != n; {
+		j := strings.Index(s[i:], m.folded)
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(m.folded)
+		if add(start, end) {
+			i = end
+		} else {
+			// A whole word may still start inside this match.
+			_, size := utf8.DecodeRuneInString(s[start:])
+			i = start + size
+		}
+	}
+	return matches
+}
+
+// fold prepares text for comparison according to the options: lowercased
+// unless case matters, and without accents if they are ignored. Regular
+// expressions are never lowercased, as that would turn escapes such as \D
+// into others; the (?i) flag takes care of case instead.
+//
+// If offsets is not nil it is set to the offset in s of every byte of the
+// result, plus len(s) at the end, or to nil if s is returned unchanged.
+func (m *Matcher) fold(s string, offsets *[]int) string {
+	lower := !m.opts.CaseSensitive && !m.opts.Regex
+	if !lower && !m.opts.IgnoreAccents {
+		if offsets != nil {
+			*offsets = nil
+		}
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	if offsets != nil {
+		*offsets = make([]int, 0, len(s)+1)
+	}
+	for i, r := range s {
+		before := sb.Len()
+		m.foldRune(&sb, r, lower)
+		if offsets != nil {
+			for range sb.Len() - before {
+				*offsets = append(*offsets, i)
+			}
+		}
+	}
+	if offsets != nil {
+		*offsets = append(*offsets, len(s))
+	}
+	return sb.String()
+}
+
+func (m *Matcher) foldRune(sb *strings.Builder, r rune, lower bool) {
+	if r < utf8.RuneSelf {
+		if lower && 'A' <= r && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		sb.WriteByte(byte(r))
+		return
+	}
+	if m.opts.IgnoreAccents {
+		if unicode.Is(unicode.Mn, r) {
+			return
+		}
+		// Keep the letter an accented one decomposes into, without its
+		// marks: "ó" is "o" followed by a combining acute accent.
+		var buf [utf8.UTFMax]byte
+		n := utf8.EncodeRune(buf[:], r)
+		if d := norm.NFD.Properties(buf[:n]).Decomposition(); d != nil {
+			for _, dr := range string(d) {
+				if !unicode.Is(unicode.Mn, dr) {
+					writeRune(sb, dr, lower)
+				}
+			}
+			return
+		}
+	}
+	writeRune(sb, r, lower)
+}
+
+func writeRune(sb *strings.Builder, r rune, lower bool) {
+	if lower {
+		r = unicode.ToLower(r)
+	}
+	sb.WriteRune(r)
+}
+
+// isWord reports whether s[start:end] is neither preceded nor followed by a
+// letter or digit.
+func isWord(s string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		term string
+		opts Options
+		err  string
+	}{
+		{"plain (text", Options{}, ""},
+		{"a.*b", Options{Regex: true}, ""},
+		{"a(b", Options{Regex: true}, "missing closing ): `a(b`"},
+		{"[z-a]", Options{Regex: true}, "invalid character class range: `z-a`"},
+		{"a(b", Options{Regex: true, Fuzzy: true}, ""},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.term, tt.opts)
+		if got := errorString(err); got != tt.err {
+			t.Errorf("New(%q, %+v) error = %q, want %q", tt.term, tt.opts, got, tt.err)
+		}
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestFindAll(t *testing.T) {
+	const line = "La Acción, la accion y las ACCIONES."
+	tests := []struct {
+		name string
+		term string
+		opts Options
+		want [][2]int
+	}{
+		{"case folded", "acción", Options{}, [][2]int{{3, 10}}},
+		{"case sensitive", "Acción", Options{CaseSensitive: true}, [][2]int{{3, 10}}},
+		{"case sensitive miss", "acción", Options{CaseSensitive: true}, nil},
+		{"accents ignored", "accion", Options{IgnoreAccents: true}, [][2]int{{3, 10}, {15, 21}, {28, 34}}},
+		{"accented term", "acción", Options{IgnoreAccents: true}, [][2]int{{3, 10}, {15, 21}, {28, 34}}},
+		{"whole word", "accion", Options{IgnoreAccents: true, WholeWord: true}, [][2]int{{3, 10}, {15, 21}}},
+		{"whole word inside", "la", Options{WholeWord: true}, [][2]int{{0, 2}, {12, 14}}},
+		{"regex", `acci[oó]n\w*`, Options{Regex: true}, [][2]int{{3, 10}, {15, 21}, {28, 36}}},
+		{"regex case sensitive", `[A-Z]+`, Options{Regex: true, CaseSensitive: true}, [][2]int{{0, 1}, {3, 4}, {28, 36}}},
+		{"regex escapes kept", `\D\d`, Options{Regex: true}, nil},
+		{"regex accents", `accion`, Options{Regex: true, IgnoreAccents: true}, [][2]int{{3, 10}, {15, 21}, {28, 34}}},
+		{"empty", "", Options{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.term, tt.opts)
+			if err != nil {
+				t.Fatalf("New(%q): %v", tt.term, err)
+			}
+			if got := m.FindAll(line); !slices.Equal(got, tt.want) {
+				t.Errorf("FindAll = %v, want %v", got, tt.want)
+			}
+			if got := m.Match(line); got != (len(tt.want) > 0) {
+				t.Errorf("Match = %v, want %v", got, len(tt.want) > 0)
+			}
+		})
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		term string
+		opts Options
+		want string
+		ok   bool
+	}{
+		{"Acción", Options{}, "acción", true},
+		{"Acción", Options{CaseSensitive: true, WholeWord: true}, "acción", true},
+		{"acci.n", Options{Regex: true}, "", false},
+		{"accion", Options{IgnoreAccents: true}, "", false},
+		{"accion", Options{Fuzzy: true}, "", false},
+	}
+	for _, tt := range tests {
+		m, err := New(tt.term, tt.opts)
+		if err != nil {
+			t.Fatalf("New(%q): %v", tt.term, err)
+		}
+		if got, ok := m.Literal(); got != tt.want || ok != tt.ok {
+			t.Errorf("Literal(%q, %+v) = %q, %v, want %q, %v", tt.term, tt.opts, got, ok, tt.want, tt.ok)
+		}
+	}
+}
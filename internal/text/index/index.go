@@ -0,0 +1,277 @@
+package index
+
+import (
+	"crypto/md5"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"txtreader/internal/text"
+)
+
+// version changes whenever the way words are normalized or stored changes,
+// so older caches are rebuilt.
+const version = 1
+
+const (
+	// minCachedSize is the size of the documents worth caching an index for.
+	// Smaller ones are indexed about as fast as their index is read.
+	minCachedSize = 4 << 20
+
+	// maxCacheSize bounds the space taken by cached indexes. The least
+	// recently used ones are removed past it.
+	maxCacheSize = 1 << 30
+)
+
+// Index maps every word of a document to the lines it occurs on.
+type Index struct {
+	words     map[string][]int32 // Line of every occurrence, in order
+	lineWords []int32            // Number of words of every line
+}
+
+func New() *Index {
+	return &Index{words: make(map[string][]int32)}
+}
+
+// Normalize turns a word into the form it is indexed under: lowercase, with
+// everything but letters and digits removed.
+func Normalize(word string) string {
+	return text.SanitizeWord(strings.ToLower(word))
+}
+
+// Add indexes the next line of the document given its words, as split by
+// strings.Fields. Lines must be added in order.
+func (idx *Index) Add(words []string) {
+	line := int32(len(idx.lineWords))
+	for _, word := range words {
+		if w := Normalize(word); w != "" {
+			idx.words[w] = append(idx.words[w], line)
+		}
+	}
+	idx.lineWords = append(idx.lineWords, int32(len(words)))
+}
+
+// Len returns the number of lines indexed.
+func (idx *Index) Len() int {
+	return len(idx.lineWords)
+}
+
+// LineWords returns the number of words of a line.
+func (idx *Index) LineWords(line int) int {
+	return int(idx.lineWords[line])
+}
+
+// Count returns how many times word occurs in the document.
+func (idx *Index) Count(word string) int {
+	return len(idx.words[Normalize(word)])
+}
+
+// Each calls fn with every word of the document and its number of occurrences.
+func (idx *Index) Each(fn func(word string, count int)) {
+	for word, lines := range idx.words {
+		fn(word, len(lines))
+	}
+}
+
+// Candidates returns, in order, the lines that may contain term, a lowercase
+// string searched for as is. Every word of term must be part of a word of a
+// matching line, so only those lines need to be checked. ok is false when
+// term has no letters or digits to look up.
+func (idx *Index) Candidates(term string) (lines []int, ok bool) {
+	var matches []bool
+	for _, token := range strings.Fields(term) {
+		token = Normalize(token)
+		if token == "" {
+			continue
+		}
+		found := make([]bool, len(idx.lineWords))
+		for word, occurrences := range idx.words {
+			if strings.Contains(word, token) {
+				for _, line := range occurrences {
+					found[line] = true
+				}
+			}
+		}
+		if matches != nil {
+			for i := range found {
+				found[i] = found[i] && matches[i]
+			}
+		}
+		matches = found
+	}
+	if matches == nil {
+		return nil, false
+	}
+
+	for i, match := range matches {
+		if match {
+			lines = append(lines, i)
+		}
+	}
+	return lines, true
+}
+
+// Hash identifies the content of a document, the key its index is cached
+// under.
+func Hash(lines []string) string {
+	h := md5.New()
+	for _, line := range lines {
+		io.WriteString(h, line)
+		io.WriteString(h, "\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Cacheable reports whether lines are large enough for their index to be
+// cached.
+func Cacheable(lines []string) bool {
+	size := 0
+	for _, line := range lines {
+		size += len(line) + 1
+		if size >= minCachedSize {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedIndex is the form an Index is stored in. Occurrences are delta
+// encoded, which makes the line numbers small enough for gob to store them in
+// a byte or two.
+type cachedIndex struct {
+	Version   int
+	Words     map[string][]int32
+	LineWords []int32
+}
+
+func cachePath(hash string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	return filepath.Join(homeDir, "ltbr", "index", hash+".gob"), nil
+}
+
+// Load returns the index cached for the document with the given hash, or nil
+// if there is none.
+func Load(hash string) (*Index, error) {
+	path, err := cachePath(hash)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading index: %v", err)
+	}
+	defer f.Close()
+
+	var cached cachedIndex
+	if err := gob.NewDecoder(f).Decode(&cached); err != nil {
+		return nil, fmt.Errorf("error parsing index: %v", err)
+	}
+	if cached.Version != version {
+		return nil, nil
+	}
+	// Mark the index as used, so it is the last to be pruned.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	for _, lines := range cached.Words {
+		for i := 1; i < len(lines); i++ {
+			lines[i] += lines[i-1]
+		}
+	}
+	return &Index{words: cached.Words, lineWords: cached.LineWords}, nil
+}
+
+// Save caches idx for the document with the given hash next to the progress
+// file, removing the least recently used indexes if the cache grows too large.
+func Save(hash string, idx *Index) error {
+	path, err := cachePath(hash)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating index directory: %v", err)
+	}
+
+	cached := cachedIndex{
+		Version:   version,
+		Words:     make(map[string][]int32, len(idx.words)),
+		LineWords: idx.lineWords,
+	}
+	for word, lines := range idx.words {
+		deltas := make([]int32, len(lines))
+		prev := int32(0)
+		for i, line := range lines {
+			deltas[i] = line - prev
+			prev = line
+		}
+		cached.Words[word] = deltas
+	}
+
+	// Write to a temporary file first so an interrupted save never leaves a
+	// truncated index behind.
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	if err := gob.NewEncoder(f).Encode(cached); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	return prune(filepath.Dir(path), maxCacheSize)
+}
+
+// prune removes the least recently used indexes of dir until the rest take no
+// more than limit bytes.
+func prune(dir string, limit int64) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading index directory: %v", err)
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".gob" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed meanwhile
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+
+	slices.SortFunc(files, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, info := range files {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing index: %v", err)
+		}
+		total -= info.Size()
+	}
+	return nil
+}
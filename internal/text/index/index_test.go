@@ -0,0 +1,158 @@
+package index
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func build(lines []string) *Index {
+	idx := New()
+	for _, line := range lines {
+		idx.Add(strings.Fields(line))
+	}
+	return idx
+}
+
+var testLines = []string{
+	"La acción empieza.",
+	"ACCIÓN en mayúsculas, acción otra vez",
+	"",
+	"Las acciones siguen.",
+	"Nada aquí.",
+}
+
+func TestIndex(t *testing.T) {
+	idx := build(testLines)
+
+	if idx.Len() != len(testLines) {
+		t.Errorf("Len = %d, want %d", idx.Len(), len(testLines))
+	}
+	for i, line := range testLines {
+		if got, want := idx.LineWords(i), len(strings.Fields(line)); got != want {
+			t.Errorf("LineWords(%d) = %d, want %d", i, got, want)
+		}
+	}
+
+	counts := []struct {
+		word string
+		want int
+	}{
+		{"acción", 3},
+		{"Acción,", 3},
+		{"acciones", 1},
+		{"missing", 0},
+	}
+	for _, tt := range counts {
+		if got := idx.Count(tt.word); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.word, got, tt.want)
+		}
+	}
+
+	words := make(map[string]int)
+	idx.Each(func(word string, count int) { words[word] = count })
+	if words["acción"] != 3 || words["la"] != 1 || len(words) != 12 {
+		t.Errorf("Each = %v", words)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	idx := build(testLines)
+	tests := []struct {
+		term  string
+		lines []int
+		ok    bool
+	}{
+		{"acción", []int{0, 1}, true},
+		{"acci", []int{0, 1, 3}, true},
+		{"acción otra", []int{1}, true},
+		{"nada.", []int{4}, true},
+		{"zzz", nil, true},
+		{"...", nil, false},
+		{"", nil, false},
+	}
+	for _, tt := range tests {
+		lines, ok := idx.Candidates(tt.term)
+		if ok != tt.ok || !slices.Equal(lines, tt.lines) {
+			t.Errorf("Candidates(%q) = %v, %v, want %v, %v", tt.term, lines, ok, tt.lines, tt.ok)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hash := Hash(testLines)
+	if hash == Hash(testLines[1:]) {
+		t.Error("Hash does not depend on the lines")
+	}
+	if idx, err := Load(hash); idx != nil || err != nil {
+		t.Fatalf("Load before Save = %v, %v, want nil, nil", idx, err)
+	}
+
+	idx := build(testLines)
+	if err := Save(hash, idx); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(hash)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !slices.Equal(loaded.lineWords, idx.lineWords) ||
+		!maps.EqualFunc(loaded.words, idx.words, func(a, b []int32) bool { return slices.Equal(a, b) }) {
+		t.Errorf("Load = %+v, want %+v", loaded, idx)
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	small := []string{"a few words", "of a short document"}
+	if Cacheable(small) {
+		t.Error("Cacheable of a short document = true")
+	}
+	large := slices.Repeat([]string{strings.Repeat("word ", 1000)}, minCachedSize/5000+1)
+	if !Cacheable(large) {
+		t.Error("Cacheable of a large document = false")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"old.gob", 3 * time.Hour},
+		{"recent.gob", time.Hour},
+		{"new.gob", 0},
+		{"other.txt", 4 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := prune(dir, 250); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	if want := []string{"new.gob", "other.txt", "recent.gob"}; !slices.Equal(left, want) {
+		t.Errorf("files left = %q, want %q", left, want)
+	}
+}
@@ -2,7 +2,6 @@ package stats
 
 import (
 	"sort"
-	"strings"
 	"txtreader/internal/text"
 )
 
@@ -11,57 +10,93 @@ type WordCount struct {
 	Count int
 }
 
-func isCommonWord(word string) bool {
-	commonWords := map[string]bool{
+var commonWords = map[string]map[string]bool{
+	"en": {
 		"the": true, "and": true, "is": true, "in": true, "to": true,
 		"a": true, "of": true, "that": true, "it": true, "on": true,
 		"for": true, "with": true, "as": true, "was": true, "at": true,
 		"by": true, "an": true, "be": true, "this": true, "from": true,
+	},
+	"es": {
 		"de": true, "que": true, "la": true, "el": true, "y": true, "en": true,
 		"se": true, "no": true, "un": true, "lo": true, "una": true, "los": true,
 		"con": true, "por": true, "su": true, "las": true, "es": true, "me": true, "del": true,
 		"le": true, "al": true, "como": true, "más": true, "para": true, "pero": true, "si": true,
 		"yo": true, "porque": true, "nos": true, "ha": true, "o": true, "cuando": true, "está": true,
-	}
-
-	return commonWords[word]
+	},
 }
 
-func TopNFrequentWords(lines []string) []WordCount {
-	wordCount := make(map[string]int)
-	for _, line := range lines {
-		words := strings.Fields(line)
-		for _, word := range words {
-			sanitized := text.SanitizeWord(word)
-			if sanitized != "" && !isCommonWord(sanitized) {
-				wordCount[sanitized] += 1
-			}
+// isCommonWord reports whether word is a stopword of language. When the
+// language is unknown every known stopword list applies.
+func isCommonWord(word, language string) bool {
+	if words, ok := commonWords[text.BaseLanguage(language)]; ok {
+		return words[word]
+	}
+	for _, words := range commonWords {
+		if words[word] {
+			return true
 		}
 	}
-	var pairs []WordCount
-	for w, c := range wordCount {
-		pairs = append(pairs, WordCount{Word: w, Count: c})
+	return false
+}
+
+// Counter counts word frequencies incrementally, so partial results can be
+// shown while a long text is still being counted.
+type Counter struct {
+	language string
+	counts   map[string]int
+	longest  string
+}
+
+func NewCounter(language string) *Counter {
+	return &Counter{language: language, counts: make(map[string]int)}
+}
+
+// AddCount counts a word n times, for counts that come from a word index.
+// Words come in no particular order, so of the longest ones the first in
+// alphabetical order is kept.
+func (c *Counter) AddCount(word string, n int) {
+	if len(word) > len(c.longest) || (len(word) == len(c.longest) && word < c.longest) {
+		c.longest = word
 	}
-	sort.Slice(pairs, func(i, j int) bool {
-		if pairs[i].Count != pairs[j].Count {
-			return pairs[i].Count > pairs[j].Count
-		}
-		return pairs[i].Word < pairs[j].Word
-	})
-	if len(pairs) > 10 {
-		return pairs[:10]
+	if word != "" && !isCommonWord(word, c.language) {
+		c.counts[word] += n
 	}
-	return pairs
 }
 
-func LongestWord(words *[]string) string {
-	longest := ""
-	for _, word := range *words {
-		sanitizedWord := text.SanitizeWord(word)
-		if len(sanitizedWord) > len(longest) {
-			longest = sanitizedWord
+// Longest returns the longest word counted so far, stopwords included.
+func (c *Counter) Longest() string {
+	return c.longest
+}
+
+// Top returns the n most frequent words counted so far, leaving stopwords out.
+func (c *Counter) Top(n int) []WordCount {
+	if n <= 0 {
+		return nil
+	}
+	// Keep the n best while scanning instead of sorting every word, so the
+	// ranking stays cheap enough to refresh during the count.
+	top := make([]WordCount, 0, n+1)
+	for w, count := range c.counts {
+		if len(top) == n && !moreFrequent(WordCount{Word: w, Count: count}, top[n-1]) {
+			continue
+		}
+		i := sort.Search(len(top), func(i int) bool {
+			return moreFrequent(WordCount{Word: w, Count: count}, top[i])
+		})
+		top = append(top, WordCount{})
+		copy(top[i+1:], top[i:])
+		top[i] = WordCount{Word: w, Count: count}
+		if len(top) > n {
+			top = top[:n]
 		}
 	}
+	return top
+}
 
-	return longest
+func moreFrequent(a, b WordCount) bool {
+	if a.Count != b.Count {
+		return a.Count > b.Count
+	}
+	return a.Word < b.Word
 }
@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter("es")
+	counts := map[string]int{
+		"casa": 3, "perro": 3, "gato": 5, "de": 9, "que": 7, "árbol": 1,
+		"zanahorias": 1, "carretera": 2, "bicicletas": 1, "": 4,
+	}
+	for word, n := range counts {
+		c.AddCount(word, n)
+	}
+
+	want := []WordCount{{"gato", 5}, {"casa", 3}, {"perro", 3}, {"carretera", 2}}
+	if got := c.Top(4); !slices.Equal(got, want) {
+		t.Errorf("Top(4) = %v, want %v", got, want)
+	}
+	if got := c.Top(0); got != nil {
+		t.Errorf("Top(0) = %v, want nil", got)
+	}
+	// Of the longest words, the first in alphabetical order, whatever the
+	// order they were counted in.
+	if got := c.Longest(); got != "bicicletas" {
+		t.Errorf("Longest = %q, want %q", got, "bicicletas")
+	}
+}
+
+func TestIsCommonWord(t *testing.T) {
+	tests := []struct {
+		word     string
+		language string
+		want     bool
+	}{
+		{"the", "en", true},
+		{"the", "en-GB", true},
+		{"the", "es", false},
+		{"que", "es-MX", true},
+		{"que", "", true},
+		{"casa", "", false},
+	}
+	for _, tt := range tests {
+		if got := isCommonWord(tt.word, tt.language); got != tt.want {
+			t.Errorf("isCommonWord(%q, %q) = %v, want %v", tt.word, tt.language, got, tt.want)
+		}
+	}
+}
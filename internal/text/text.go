@@ -24,3 +24,9 @@ func SanitizeWord(word string) string {
 	}
 	return sb.String()
 }
+
+// BaseLanguage reduces a language tag such as "es-MX" to its primary subtag.
+func BaseLanguage(tag string) string {
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	return strings.ToLower(strings.TrimSpace(base))
+}
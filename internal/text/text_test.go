@@ -0,0 +1,39 @@
+package text
+
+import "testing"
+
+func TestSanitizeWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"word", "word"},
+		{"«acción»,", "acción"},
+		{"don't", "dont"},
+		{"1984.", "1984"},
+		{"—", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeWord(tt.word); got != tt.want {
+			t.Errorf("SanitizeWord(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestBaseLanguage(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"es", "es"},
+		{"es-MX", "es"},
+		{"en_US", "en"},
+		{" FR ", "fr"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := BaseLanguage(tt.tag); got != tt.want {
+			t.Errorf("BaseLanguage(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
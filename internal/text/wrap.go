@@ -0,0 +1,107 @@
+package text
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Row is a visual row of a wrapped line, the bytes [Start, End) of it.
+type Row struct {
+	Start int
+	End   int
+}
+
+// WordSpans returns the byte ranges of the words of s, the same words
+// strings.Fields would return.
+func WordSpans(s string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, [2]int{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(s)})
+	}
+	return spans
+}
+
+// Wrap breaks line into rows no wider than width columns. Lines are broken at
+// whitespace, which is dropped at the break, and words longer than a row are
+// split. The indentation of the line is kept on its first row.
+func Wrap(line string, width int) []Row {
+	if width <= 0 || StringWidth(line) <= width {
+		return []Row{{Start: 0, End: len(line)}}
+	}
+
+	var rows []Row
+	start, col := 0, 0
+	// Whitespace run following the last word of the row, [brk, brkEnd).
+	brk, brkEnd := -1, -1
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		w := RuneWidth(r)
+		if unicode.IsSpace(r) {
+			// Spaces may overflow the row since they are dropped at the break.
+			if brkEnd != i {
+				brk = i
+			}
+			brkEnd = i + size
+			col += w
+			i += size
+			continue
+		}
+
+		for col+w > width && i > start {
+			if brk > start {
+				rows = append(rows, Row{Start: start, End: brk})
+				start = brkEnd
+				col = StringWidth(line[start:i])
+			} else {
+				rows = append(rows, Row{Start: start, End: i})
+				start = i
+				col = 0
+			}
+			brk, brkEnd = -1, -1
+		}
+		col += w
+		i += size
+	}
+	return append(rows, Row{Start: start, End: len(line)})
+}
+
+// RowOf returns the index of the row holding the byte at offset.
+func RowOf(rows []Row, offset int) int {
+	for i, row := range rows {
+		if offset < row.End {
+			return i
+		}
+	}
+	return len(rows) - 1
+}
+
+// StringWidth is the number of terminal columns s takes.
+func StringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// RuneWidth is the number of terminal columns r takes. Tabs are expanded to
+// four spaces when rendered.
+func RuneWidth(r rune) int {
+	if r == '\t' {
+		return 4
+	}
+	return runewidth.RuneWidth(r)
+}
@@ -0,0 +1,98 @@
+package text
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func rowText(line string, rows []Row) []string {
+	var texts []string
+	for _, row := range rows {
+		texts = append(texts, line[row.Start:row.End])
+	}
+	return texts
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		width int
+		want  []string
+	}{
+		{"fits", "short line", 20, []string{"short line"}},
+		{"no width", "short line", 0, []string{"short line"}},
+		{"empty", "", 10, []string{""}},
+		{"words", "the quick brown fox jumps", 10, []string{"the quick", "brown fox", "jumps"}},
+		{"exact fit", "aaaa bbbb", 4, []string{"aaaa", "bbbb"}},
+		{"space runs", "aaaa    bbbb", 6, []string{"aaaa", "bbbb"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"long word after text", "ab cdefghij", 4, []string{"ab", "cdef", "ghij"}},
+		{"indentation", "    indented text here", 12, []string{"    indented", "text here"}},
+		{"wide runes", "日本語の文章", 6, []string{"日本語", "の文章"}},
+		{"accents", "acción canción", 7, []string{"acción", "canción"}},
+		{"tabs", "\tab cd", 6, []string{"\tab", "cd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := Wrap(tt.line, tt.width)
+			if got := rowText(tt.line, rows); !slices.Equal(got, tt.want) {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.want)
+			}
+			for _, row := range rows {
+				if w := StringWidth(tt.line[row.Start:row.End]); tt.width > 0 && w > tt.width && !strings.ContainsRune(tt.line, '\t') {
+					t.Errorf("row %q is %d columns wide", tt.line[row.Start:row.End], w)
+				}
+			}
+		})
+	}
+}
+
+func TestRowOf(t *testing.T) {
+	line := "the quick brown fox"
+	rows := Wrap(line, 10) // "the quick", "brown fox"
+	tests := []struct {
+		offset int
+		want   int
+	}{
+		{0, 0},
+		{8, 0},
+		{9, 1}, // The space dropped at the break
+		{10, 1},
+		{len(line), 1},
+	}
+	for _, tt := range tests {
+		if got := RowOf(rows, tt.offset); got != tt.want {
+			t.Errorf("RowOf(%d) = %d, want %d", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestWordSpans(t *testing.T) {
+	s := "  one\ttwo  tres cuatro "
+	var words []string
+	for _, span := range WordSpans(s) {
+		words = append(words, s[span[0]:span[1]])
+	}
+	if want := strings.Fields(s); !slices.Equal(words, want) {
+		t.Errorf("WordSpans(%q) = %q, want %q", s, words, want)
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"abc", 3},
+		{"acción", 6},
+		{"日本", 4},
+		{"\t", 4},
+	}
+	for _, tt := range tests {
+		if got := StringWidth(tt.s); got != tt.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
@@ -1,29 +1,34 @@
 package ui
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"maps"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"txtreader/internal/document"
+	"txtreader/internal/model"
 	"txtreader/internal/progress"
+	"txtreader/internal/search"
 	"txtreader/internal/text"
+	"txtreader/internal/text/index"
 	"txtreader/internal/text/stats"
 	"txtreader/internal/utils"
+	"unicode/utf8"
 
 	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-
-	"github.com/taylorskalyo/goreader/epub"
-	"golang.org/x/net/html"
 )
 
 type UiModel struct {
@@ -36,6 +41,7 @@ type UiModel struct {
 	tabs                  []string
 	width, height         int
 	filePath              string
+	docID                 string // Logical document the progress is stored under
 	showGotoLineDialog    bool
 	lineInput             string
 	tabWidths             []int // Store rendered width of each tab
@@ -55,24 +61,72 @@ type UiModel struct {
 	longestWord           string
 	topWords              []stats.WordCount
 	cumulativeWords       []int   // Cumulative words up to each line
+	statsReady            bool    // Statistics and word counts computed by Init
+	statsProgress         float64 // Fraction of the lines counted so far
+	statsUpdates          chan tea.Msg
+	statsCtx              context.Context // Cancelled on quit to stop the count
+	wordIndex             *index.Index    // Lines of every word, built with the statistics
+	cancelStats           context.CancelFunc
 	totalReadingSeconds   float64 // Total reading time in seconds (loaded from progress)
 	totalReadWords        int     // Total words read (loaded from progress)
 	sessionReadingTime    float64 // Session reading time in seconds
 	sessionWordsRead      int     // Session words read
 	lastActionTime        time.Time
-	vp                    viewport.Model // Viewport para manejar scroll en el tab de texto
+	vp                    viewport.Model // Tamaño y teclas de scroll del tab de texto
+	topLine               int            // Line shown at the top of the Texto tab
+	topRow                int            // First visual row of topLine shown, when it wraps
+	textWidth             int            // Maximum width of the reading column
 	showHelpDialog        bool
 	showSearchDialog      bool
 	searchInput           string
-	searchResults         []int  // Índices de líneas que contienen el término
-	currentSearchIdx      int    // Índice actual en searchResults
-	searchTerm            string // Término de búsqueda actual
+	searchResults         []int          // Índices de líneas que contienen el término
+	currentSearchIdx      int            // Índice actual en searchResults
+	searchTerm            string         // Término de búsqueda actual
+	searchOptions         search.Options // Options toggled in the search dialog
+	searchMatcher         *search.Matcher
+	currentMatchIdx       int             // Match within the current result line
+	searchHighlight       bool            // Matches of the last search are highlighted
+	searchError           string          // Why the last term could not be searched for
+	searchPreview         *search.Matcher // Term being typed, shown as it is typed
+	searchPreviewID       int             // Latest preview scan, results of older ones are dropped
+	searchOrigin          cursor          // Where the cursor was when the dialog opened
+	searchHistory         []string        // Terms searched for, oldest first
+	historyIdx            int             // Entry of searchHistory in the dialog, len for a new term
+	searchDraft           string          // Term typed before browsing the history
+	cancelPreview         context.CancelFunc
 	vocabVP               viewport.Model
 	noteTA                textarea.Model
+	lineKinds             map[int]document.LineKind
+	lineSpans             map[int][]document.Span
+	chapters              []document.Chapter // Table of contents, sorted by line
+	cues                  []document.Cue     // Subtitle timings, sorted by line
+	metadata              document.Metadata
+	gutenberg             bool   // Gutenberg boilerplate stripped and lines reflowed
+	encoding              string // Encoding forced for the document, empty when detected
+	doc                   *document.Document
+	showTOCDialog         bool
+	currentTOCIdx         int // Track selected chapter in the TOC dialog
+	showResultsDialog     bool
+	currentResultIdx      int // Track selected line in the search results dialog
+	showLibraryDialog     bool
+	libraryInput          string
+	librarySearchID       int // Latest library search, results of older ones are dropped
+	librarySearching      bool
+	libraryMatcher        *search.Matcher
+	libraryHits           []libraryHit // nil until the search finishes
+	libraryStatus         string       // Outcome of the library search, or why it failed
+	currentLibraryIdx     int          // Track selected match in the library dialog
 }
 
 const DefaultWPM = 250.0
 
+// Width of the reading column of the Texto tab, adjusted with [ and ].
+const (
+	defaultTextWidth = 80
+	minTextWidth     = 20
+	textWidthStep    = 5
+)
+
 const (
 	brightWhiteColor  = lipgloss.Color("15")
 	blueColor         = lipgloss.Color("27")
@@ -86,6 +140,8 @@ const (
 	greenColor        = lipgloss.Color("28")
 	greyColor         = lipgloss.Color("235")
 	grayColor         = lipgloss.Color("240")
+	matchColor        = lipgloss.Color("94")
+	currentMatchColor = lipgloss.Color("208")
 )
 
 const (
@@ -118,9 +174,31 @@ const (
 	keySearch                   = "/"
 	keyNextSearch               = "n"
 	keyPrevSearch               = "N"
+	keyToggleRegex              = "ctrl+r"
+	keyToggleAccents            = "ctrl+a"
+	keyToggleCase               = "ctrl+u"
+	keyToggleWholeWord          = "ctrl+w"
+	keyToggleFuzzy              = "ctrl+f"
+	keyFuzzyDistance            = "ctrl+d"
+	keyClearSearch              = "x"
+	keySearchResults            = "r"
+	keyLibrarySearch            = "L"
+	keyTOCDialog                = "t"
+	keyNarrowerText             = "["
+	keyWiderText                = "]"
 )
 
-func InitialModel(filePath string) (UiModel, error) {
+// Options are the command line settings used to open a document.
+type Options struct {
+	// Encoding forces the character encoding of the document. When empty, the
+	// one forced before for the book is used, or else it is detected.
+	Encoding string
+	// Gutenberg strips Project Gutenberg boilerplate and reflows hard-wrapped
+	// lines. When nil, the setting remembered for the book is used.
+	Gutenberg *bool
+}
+
+func InitialModel(filePath string, opts Options) (UiModel, error) {
 	m := UiModel{
 		tabs:                 []string{"Texto", "Vocabulario", "Notas", "Estadísticas"},
 		currentTab:           0,
@@ -161,110 +239,95 @@ func InitialModel(filePath string) (UiModel, error) {
 
 	m.filePath = filePath
 
-	var fullText string
-	if strings.HasSuffix(strings.ToLower(filePath), ".epub") {
-		rc, err := epub.OpenReader(filePath)
-		if err != nil {
-			return UiModel{}, fmt.Errorf("error opening EPUB: %v", err)
-		}
-		defer rc.Close()
-
-		if len(rc.Rootfiles) == 0 {
-			return UiModel{}, fmt.Errorf("no rootfiles found in EPUB")
-		}
-		book := rc.Rootfiles[0]
+	doc, err := document.Open(filePath, document.Options{Encoding: opts.Encoding})
+	if err != nil {
+		return UiModel{}, err
+	}
 
-		var textBuilder strings.Builder
-		for _, itemref := range book.Spine.Itemrefs {
-			var item *epub.Item
-			for _, manifestItem := range book.Manifest.Items {
-				if manifestItem.ID == itemref.IDREF {
-					item = &manifestItem
-					break
-				}
-			}
-			if item == nil {
-				continue
-			}
-			reader, err := item.Open()
-			if err != nil {
-				continue
-			}
-			doc, err := html.Parse(reader)
-			if err != nil {
-				reader.Close()
-				continue
-			}
-			reader.Close()
-
-			// Extract text from HTML nodes
-			var extractText func(*html.Node)
-			extractText = func(n *html.Node) {
-				if n.Type == html.TextNode {
-					trimmed := strings.TrimSpace(n.Data)
-					if trimmed != "" {
-						textBuilder.WriteString(trimmed)
-						textBuilder.WriteString("\n")
-					}
-				}
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					extractText(c)
-				}
-			}
-			extractText(doc)
-		}
+	// Files are stored under their absolute path, so the progress is found
+	// whatever directory the reader is started from.
+	m.docID = doc.ID
+	if filePath != document.StdinPath {
+		m.docID = absPath(doc.ID)
+	}
 
-		fullText = textBuilder.String()
-	} else {
-		// Plain text
-		file, err := os.Open(filePath)
+	// Load progress for the file
+	entry, err := progress.Load(m.docID)
+	if err != nil {
+		return UiModel{}, err
+	}
+	if entry.FileName == "" && m.docID != doc.ID {
+		// Stored under the path as typed by earlier versions
+		entry, err = progress.Load(doc.ID)
 		if err != nil {
 			return UiModel{}, err
 		}
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
-		var textBuilder strings.Builder
-		for scanner.Scan() {
-			textBuilder.WriteString(scanner.Text())
-			textBuilder.WriteString("\n")
-		}
-		if err := scanner.Err(); err != nil {
+	}
+	m.searchHistory, err = search.LoadHistory()
+	if err != nil {
+		return UiModel{}, err
+	}
+
+	// Standard input cannot be read again, so only files get the encoding
+	// remembered for them.
+	m.encoding = opts.Encoding
+	if m.encoding == "" && filePath != document.StdinPath {
+		m.encoding = entry.Encoding
+	}
+	if m.encoding != opts.Encoding {
+		doc.Close()
+		doc, err = document.Open(filePath, document.Options{Encoding: m.encoding})
+		if err != nil {
 			return UiModel{}, err
 		}
-		fullText = textBuilder.String()
 	}
+	m.doc = doc
 
-	m.lines = strings.Split(fullText, "\n")
-
-	// Compute cumulative words
-	m.cumulativeWords = make([]int, len(m.lines)+1)
-	m.cumulativeWords[0] = 0
-	for i := range m.lines {
-		words := strings.Fields(m.lines[i])
-		m.cumulativeWords[i+1] = m.cumulativeWords[i] + len(words)
+	m.gutenberg = entry.Gutenberg
+	if opts.Gutenberg != nil {
+		m.gutenberg = *opts.Gutenberg
+	}
+	// The line is stored in the layout the book was read in, so it is
+	// translated when the setting changed since.
+	line := entry.Line
+	if m.gutenberg != entry.Gutenberg {
+		cleaned := doc
+		if !m.gutenberg {
+			copied := *doc
+			cleaned = &copied
+		}
+		if starts := document.CleanGutenberg(cleaned); starts != nil {
+			line = gutenbergLine(line, starts, m.gutenberg)
+		}
+	} else if m.gutenberg {
+		document.CleanGutenberg(doc)
 	}
-	m.totalWords = m.cumulativeWords[len(m.lines)]
 
-	calculateStatistics(&m)
+	m.lines = doc.Lines
+	m.lineKinds = doc.Kinds
+	m.lineSpans = doc.Spans
+	m.chapters = doc.Chapters
+	m.cues = doc.Cues
+	m.metadata = doc.Metadata
+	m.totalLines = len(m.lines)
+	m.statsUpdates = make(chan tea.Msg)
+	m.statsCtx, m.cancelStats = context.WithCancel(context.Background())
 
-	// Load progress for the file
-	line, vocab, notes, readingSeconds, readWords, err := progress.Load(m.filePath)
-	if err != nil {
-		return UiModel{}, err
-	}
 	if line > 0 && line < len(m.lines) {
 		m.currentLine = line
 	}
-	if vocab == nil {
-		vocab = []string{}
+	if entry.Vocabulary != nil {
+		m.vocabulary = entry.Vocabulary
 	}
-	if notes == nil {
-		notes = []string{}
+	if entry.Notes != nil {
+		m.notes = entry.Notes
 	}
-	m.vocabulary = vocab
-	m.notes = notes
-	m.totalReadingSeconds = readingSeconds
-	m.totalReadWords = readWords
+	m.totalReadingSeconds = entry.ReadingSeconds
+	m.textWidth = defaultTextWidth
+	if entry.TextWidth > 0 {
+		m.textWidth = utils.Max(minTextWidth, entry.TextWidth)
+	}
+	m.totalReadWords = entry.ReadWords
 
 	m.vp = viewport.New(0, 0) // Initialize to 0, Update() will set it.
 	m.vp.MouseWheelEnabled = true
@@ -281,43 +344,205 @@ func InitialModel(filePath string) (UiModel, error) {
 	m.updateVocabContent()
 	m.syncVocabOffset() // Center initially
 
-	m.vp.SetContent(strings.Join(m.lines, "\n"))
-
 	return m, nil
 }
 
-func calculateStatistics(m *UiModel) {
-	m.totalLines = len(m.lines)
-	var totalWords int
+// statsMsg delivers the statistics computed in the background by
+// calculateStatistics.
+type statsMsg struct {
+	cumulativeWords   []int
+	totalWords        int
+	longestLine       string
+	longestLineLength int
+	longestWord       string
+	topWords          []stats.WordCount
+	index             *index.Index
+	updates           chan<- tea.Msg // Channel of the document the statistics are of
+}
+
+// statsProgressMsg delivers the statistics of the lines counted so far.
+type statsProgressMsg struct {
+	partial  statsMsg
+	progress float64
+}
+
+// statsUpdateInterval is how often partial statistics are sent.
+const statsUpdateInterval = 200 * time.Millisecond
+
+// calculateStatistics counts the words of lines, sending a statsProgressMsg
+// to updates every statsUpdateInterval and a statsMsg once done. The word
+// index is built on the way, or loaded if it was cached by an earlier run. It
+// gives up when ctx is cancelled. updates is closed on return.
+func calculateStatistics(ctx context.Context, lines []string, language string, updates chan<- tea.Msg) {
+	defer close(updates)
+
+	// Only large documents have their index cached, small ones are indexed
+	// about as fast.
+	var hash string
+	var idx *index.Index
+	if index.Cacheable(lines) {
+		hash = index.Hash(lines)
+		if loaded, err := index.Load(hash); err == nil && loaded != nil && loaded.Len() == len(lines) {
+			idx = loaded
+		}
+	}
+	cached := idx != nil
+	if !cached {
+		idx = index.New()
+	}
+
+	cumulativeWords := make([]int, len(lines)+1)
 	var maxLen int
 	var longest string
-	var longestWordInLine string
-	for _, line := range m.lines {
-		words := strings.Fields(line)
-		longestWord := stats.LongestWord(&words)
-		if len(longestWord) > len(longestWordInLine) {
-			longestWordInLine = longestWord
-		}
-		totalWords += len(words)
+	lastUpdate := time.Now()
+	for i, line := range lines {
+		// Checking the clock on every line would slow the count down.
+		if i%4096 == 4095 {
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(lastUpdate) >= statsUpdateInterval {
+				topWords, longestWord := wordStats(idx, language)
+				msg := statsProgressMsg{
+					partial: statsMsg{
+						updates:           updates,
+						totalWords:        cumulativeWords[i],
+						longestLine:       longest,
+						longestLineLength: maxLen,
+						longestWord:       longestWord,
+						topWords:          topWords,
+					},
+					progress: float64(i) / float64(len(lines)),
+				}
+				select {
+				case updates <- msg:
+				case <-ctx.Done():
+					return
+				}
+				lastUpdate = time.Now()
+			}
+		}
+
+		var words int
+		if cached {
+			words = idx.LineWords(i)
+		} else {
+			fields := strings.Fields(line)
+			idx.Add(fields)
+			words = len(fields)
+		}
+		cumulativeWords[i+1] = cumulativeWords[i] + words
 		if len(line) > maxLen {
 			maxLen = len(line)
 			longest = line
 		}
 	}
+	if !cached && hash != "" {
+		// The index is only a cache, it is built again if it cannot be saved.
+		_ = index.Save(hash, idx)
+	}
 
-	m.totalWords = totalWords
-	m.longestLine = longest
-	m.longestLineLength = maxLen
-	m.longestWord = longestWordInLine
-	m.topWords = stats.TopNFrequentWords(m.lines)
+	topWords, longestWord := wordStats(idx, language)
+	msg := statsMsg{
+		updates:           updates,
+		cumulativeWords:   cumulativeWords,
+		totalWords:        cumulativeWords[len(lines)],
+		longestLine:       longest,
+		longestLineLength: maxLen,
+		longestWord:       longestWord,
+		topWords:          topWords,
+		index:             idx,
+	}
+	select {
+	case updates <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// wordStats ranks the words of idx, leaving the stopwords of language out,
+// and finds the longest one.
+func wordStats(idx *index.Index, language string) ([]stats.WordCount, string) {
+	counter := stats.NewCounter(language)
+	idx.Each(counter.AddCount)
+	return counter.Top(10), counter.Longest()
 }
 
+// waitForStats waits for the next message of calculateStatistics.
+func waitForStats(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates // nil once the channel is closed
+	}
+}
+
+// Init starts computing the statistics, which can take a while for large
+// files, so the text shows up right away. The count stops when quitting.
 func (m UiModel) Init() tea.Cmd {
-	return nil
+	ctx, lines, language, updates := m.statsCtx, m.lines, m.metadata.Language, m.statsUpdates
+	return func() tea.Msg {
+		go calculateStatistics(ctx, lines, language, updates)
+		return <-updates
+	}
 }
 
 func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case statsMsg:
+		if msg.updates != m.statsUpdates {
+			// Left over from a document opened before this one
+			return m, nil
+		}
+		m.cumulativeWords = msg.cumulativeWords
+		m.totalWords = msg.totalWords
+		m.longestLine = msg.longestLine
+		m.longestLineLength = msg.longestLineLength
+		m.longestWord = msg.longestWord
+		m.topWords = msg.topWords
+		m.wordIndex = msg.index
+		m.statsReady = true
+		m.statsProgress = 1
+		return m, nil
+	case searchPreviewMsg:
+		if msg.id != m.searchPreviewID {
+			// The term changed or the dialog was closed meanwhile
+			return m, nil
+		}
+		m.cancelPreview = nil
+		if !msg.found {
+			m.restoreCursor(m.searchOrigin)
+			return m, nil
+		}
+		m.currentLine = msg.line
+		m.currentWordIdx = msg.wordIdx
+		m.syncViewportOffset()
+		return m, nil
+	case libraryResultsMsg:
+		if msg.id != m.librarySearchID {
+			// The dialog was closed, or another search started
+			return m, nil
+		}
+		m.librarySearching = false
+		m.libraryHits = append([]libraryHit{}, msg.hits...)
+		m.currentLibraryIdx = 0
+		m.libraryStatus = fmt.Sprintf("%d resultados en %d documentos", len(msg.hits), msg.searched)
+		if len(msg.hits) == maxLibraryHits {
+			m.libraryStatus += fmt.Sprintf(" (solo los primeros %d)", maxLibraryHits)
+		}
+		if msg.failed > 0 {
+			m.libraryStatus += fmt.Sprintf(", %d no se pudieron abrir", msg.failed)
+		}
+		return m, nil
+	case statsProgressMsg:
+		if msg.partial.updates != m.statsUpdates {
+			return m, nil
+		}
+		m.totalWords = msg.partial.totalWords
+		m.longestLine = msg.partial.longestLine
+		m.longestLineLength = msg.partial.longestLineLength
+		m.longestWord = msg.partial.longestWord
+		m.topWords = msg.partial.topWords
+		m.statsProgress = msg.progress
+		return m, waitForStats(m.statsUpdates)
+
 	case tea.KeyMsg:
 		if m.showHelpDialog {
 			switch msg.String() {
@@ -329,40 +554,161 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Manejo del diálogo de búsqueda
 		if m.showSearchDialog {
+			var cmd tea.Cmd
 			switch msg.String() {
-			case keyEsc:
+			case keyEsc, keyCancel:
+				// Go back to where the search started
+				m.stopPreview()
+				m.restoreCursor(m.searchOrigin)
 				m.showSearchDialog = false
+				m.searchPreview = nil
 				m.searchInput = ""
+				m.searchError = ""
 			case keyEnter:
+				m.stopPreview()
 				if m.searchInput != "" {
 					// We have something in the input, perform the search...
-					m.searchTerm = strings.ToLower(m.searchInput)
-					m.searchResults = m.performSearch(m.searchTerm)
+					matcher, err := search.New(m.searchInput, m.searchOptions)
+					if err != nil {
+						// Keep the dialog open so the pattern can be fixed
+						m.searchError = err.Error()
+						return m, nil
+					}
+					m.searchHistory = search.AddToHistory(m.searchHistory, m.searchInput)
+					if err := search.SaveHistory(m.searchHistory); err != nil {
+						fmt.Printf("Error saving search history: %v\n", err)
+					}
+					// Results are listed from where the search started
+					m.restoreCursor(m.searchOrigin)
+					m.searchTerm = m.searchInput
+					m.searchMatcher = matcher
+					m.searchResults = m.performSearch(matcher)
+					m.searchHighlight = true
 
 					if len(m.searchResults) > 0 {
 						// Go to the first result
 						m.currentSearchIdx = 0
-						m.currentLine = m.searchResults[0]
-						m.currentWordIdx = 0
-						m.syncViewportOffset()
+						m.currentMatchIdx = 0
+						m.goToCurrentMatch()
 					}
 				}
 				m.showSearchDialog = false
+				m.searchPreview = nil
 				m.searchInput = ""
+				m.searchError = ""
+			case keyToggleRegex:
+				m.searchOptions.Regex = !m.searchOptions.Regex
+				m.searchError = ""
+				cmd = m.previewSearch()
+			case keyToggleAccents:
+				m.searchOptions.IgnoreAccents = !m.searchOptions.IgnoreAccents
+				cmd = m.previewSearch()
+			case keyToggleCase:
+				m.searchOptions.CaseSensitive = !m.searchOptions.CaseSensitive
+				cmd = m.previewSearch()
+			case keyToggleWholeWord:
+				m.searchOptions.WholeWord = !m.searchOptions.WholeWord
+				cmd = m.previewSearch()
+			case keyToggleFuzzy:
+				m.searchOptions.Fuzzy = !m.searchOptions.Fuzzy
+				m.searchError = ""
+				cmd = m.previewSearch()
+			case keyFuzzyDistance:
+				// Cycle through 1, 2 and 3 edits
+				m.searchOptions.MaxDistance = fuzzyDistance(m.searchOptions)%3 + 1
+				cmd = m.previewSearch()
+			case "up":
+				// Older term in the history
+				if m.historyIdx > 0 {
+					if m.historyIdx == len(m.searchHistory) {
+						m.searchDraft = m.searchInput
+					}
+					m.historyIdx--
+					m.searchInput = m.searchHistory[m.historyIdx]
+					m.searchError = ""
+					cmd = m.previewSearch()
+				}
+			case "down":
+				// Newer term in the history, then back to the one being typed
+				if m.historyIdx < len(m.searchHistory) {
+					m.historyIdx++
+					if m.historyIdx == len(m.searchHistory) {
+						m.searchInput = m.searchDraft
+					} else {
+						m.searchInput = m.searchHistory[m.historyIdx]
+					}
+					m.searchError = ""
+					cmd = m.previewSearch()
+				}
 			case keyBackspace:
 				if len(m.searchInput) > 0 {
-					m.searchInput = m.searchInput[:len(m.searchInput)-1]
+					_, size := utf8.DecodeLastRuneInString(m.searchInput)
+					m.searchInput = m.searchInput[:len(m.searchInput)-size]
 				}
-			case keyCancel:
-				m.showSearchDialog = false
-				m.searchInput = ""
+				m.searchError = ""
+				cmd = m.previewSearch()
 			default:
 				// Capture text input
 				if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
 					for _, r := range msg.Runes {
 						m.searchInput += string(r)
 					}
+					m.searchError = ""
+					cmd = m.previewSearch()
+				}
+			}
+			return m, cmd
+		}
+
+		if m.showTOCDialog {
+			switch msg.String() {
+			case keyEsc, keyCancel, keyTOCDialog:
+				m.showTOCDialog = false
+			case keyNextLine, "down":
+				if m.currentTOCIdx < len(m.chapters)-1 {
+					m.currentTOCIdx++
+				}
+			case keyPrevLine, "up":
+				if m.currentTOCIdx > 0 {
+					m.currentTOCIdx--
+				}
+			case keyEnter:
+				m.currentLine = utils.Min(len(m.lines)-1, m.chapters[m.currentTOCIdx].Line)
+				m.currentWordIdx = 0
+				m.lastActionTime = time.Now() // Reset action time after jump
+				m.syncViewportOffset()
+				m.showTOCDialog = false
+			}
+			return m, nil
+		}
+
+		if m.showLibraryDialog {
+			return m.updateLibraryDialog(msg)
+		}
+
+		if m.showResultsDialog {
+			switch msg.String() {
+			case keyEsc, keyCancel, keySearchResults:
+				m.showResultsDialog = false
+			case keyNextLine, "down":
+				if m.currentResultIdx < len(m.searchResults)-1 {
+					m.currentResultIdx++
+				}
+			case keyPrevLine, "up":
+				if m.currentResultIdx > 0 {
+					m.currentResultIdx--
 				}
+			case "pgdown":
+				m.currentResultIdx = utils.Min(len(m.searchResults)-1, m.currentResultIdx+m.resultsPageSize())
+			case "pgup":
+				m.currentResultIdx = utils.Max(0, m.currentResultIdx-m.resultsPageSize())
+			case keyEnter:
+				m.currentSearchIdx = m.currentResultIdx
+				m.currentMatchIdx = 0
+				m.searchHighlight = true
+				m.goToCurrentMatch()
+				m.lastActionTime = time.Now() // Reset action time after jump
+				m.showResultsDialog = false
 			}
 			return m, nil
 		}
@@ -378,6 +724,19 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.currentLine = lineNum - 1   // Convert to 0-based index
 						m.currentWordIdx = 0          // Reset word index
 						m.lastActionTime = time.Now() // Reset action time after jump
+						m.syncViewportOffset()
+					} else if at, err := document.ParseTimestamp(m.lineInput); err == nil && len(m.cues) > 0 {
+						// Jump to the subtitle shown at that time, or the next one.
+						m.currentLine = m.cues[len(m.cues)-1].Line
+						for _, cue := range m.cues {
+							if cue.End > at {
+								m.currentLine = cue.Line
+								break
+							}
+						}
+						m.currentWordIdx = 0
+						m.lastActionTime = time.Now()
+						m.syncViewportOffset()
 					}
 				}
 				m.showGotoLineDialog = false
@@ -389,6 +748,9 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			default:
 				if len(msg.String()) == 1 && msg.String() >= "0" && msg.String() <= "9" {
 					m.lineInput += msg.String()
+				} else if len(m.cues) > 0 && len(msg.String()) == 1 && strings.Contains(":.,", msg.String()) {
+					// Subtitles can also be jumped to by time, e.g. 01:02:03
+					m.lineInput += msg.String()
 				}
 			}
 			return m, nil
@@ -422,7 +784,7 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showLinksDialog = false
 				m.currentLinkIdx = 0
 			case keyNextLine, "down":
-				if m.currentLinkIdx < 1 { // Only two items: GoodReads, RAE
+				if m.currentLinkIdx < len(m.links())-1 {
 					m.currentLinkIdx++
 				}
 			case keyPrevLine, "up":
@@ -431,14 +793,14 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case keyEnter:
 				// Open the selected link in the default browser
-				links := []string{"https://dle.rae.es/%s", "https://www.goodreads.com/search?q=%s"}
+				links := m.links()
 				if m.currentLinkIdx >= 0 && m.currentLinkIdx < len(links) {
 					words := strings.Fields(m.lines[m.currentLine])
 					var currentWord string
 					if len(words) > 0 && m.currentWordIdx < len(words) {
 						currentWord = words[m.currentWordIdx]
 					}
-					urlToSearch := fmt.Sprintf(links[m.currentLinkIdx], url.QueryEscape(text.SanitizeWord(currentWord)))
+					urlToSearch := fmt.Sprintf(links[m.currentLinkIdx].url, url.QueryEscape(text.SanitizeWord(currentWord)))
 					if err := browserOpenURLCommand(runtime.GOOS, urlToSearch).Start(); err != nil {
 						fmt.Printf("Error opening browser: %v\n", err)
 					}
@@ -488,35 +850,48 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.currentTab == 0 {
 				m.showSearchDialog = true
 				m.searchInput = ""
+				m.searchOrigin = m.cursor()
+				m.historyIdx = len(m.searchHistory)
+				m.searchDraft = ""
 			}
 			return m, nil
 		case keyNextSearch:
-			// Go to the next search result
+			// Go to the next match, on this result line or the next one
 			if len(m.searchResults) > 0 {
-				m.currentSearchIdx = (m.currentSearchIdx + 1) % len(m.searchResults)
-				m.currentLine = m.searchResults[m.currentSearchIdx]
-				m.currentWordIdx = 0
-				m.syncViewportOffset()
+				m.currentMatchIdx++
+				if m.currentMatchIdx >= len(m.searchMatcher.FindAll(m.lines[m.searchResults[m.currentSearchIdx]])) {
+					m.currentSearchIdx = (m.currentSearchIdx + 1) % len(m.searchResults)
+					m.currentMatchIdx = 0
+				}
+				m.searchHighlight = true
+				m.goToCurrentMatch()
 			}
 			return m, nil
 		case keyPrevSearch:
-			// Go to the previous search result
+			// Go to the previous match, on this result line or the previous one
 			if len(m.searchResults) > 0 {
-				m.currentSearchIdx--
-				if m.currentSearchIdx < 0 {
-					m.currentSearchIdx = len(m.searchResults) - 1
+				m.currentMatchIdx--
+				if m.currentMatchIdx < 0 {
+					m.currentSearchIdx--
+					if m.currentSearchIdx < 0 {
+						m.currentSearchIdx = len(m.searchResults) - 1
+					}
+					m.currentMatchIdx = len(m.searchMatcher.FindAll(m.lines[m.searchResults[m.currentSearchIdx]])) - 1
 				}
-				m.currentLine = m.searchResults[m.currentSearchIdx]
-				m.currentWordIdx = 0
-				m.syncViewportOffset()
+				m.searchHighlight = true
+				m.goToCurrentMatch()
 			}
 			return m, nil
+		case keyClearSearch:
+			m.searchHighlight = false
+			return m, nil
 		case keyCancel, keyQuit:
+			m.cancelStats()
 			// Save progress before quitting
 			m.totalReadingSeconds += m.sessionReadingTime
 			m.totalReadWords += m.sessionWordsRead
 			if m.filePath != "" {
-				if err := progress.Save(m.filePath, m.currentLine, m.vocabulary, m.notes, m.totalReadingSeconds, m.totalReadWords); err != nil {
+				if err := progress.Save(m.docID, m.progressEntry()); err != nil {
 					fmt.Printf("Error saving progress: %v\n", err)
 				}
 			}
@@ -525,7 +900,7 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.filePath != "" {
 				m.totalReadingSeconds += m.sessionReadingTime
 				m.totalReadWords += m.sessionWordsRead
-				if err := progress.Save(m.filePath, m.currentLine, m.vocabulary, m.notes, m.totalReadingSeconds, m.totalReadWords); err != nil {
+				if err := progress.Save(m.docID, m.progressEntry()); err != nil {
 					fmt.Printf("Error saving progress: %v\n", err)
 				}
 				m.sessionReadingTime = 0
@@ -554,6 +929,23 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showGotoLineDialog = true
 				m.lineInput = ""
 			}
+		case keyTOCDialog:
+			if m.currentTab == 0 && len(m.chapters) > 0 {
+				m.showTOCDialog = true
+				m.currentTOCIdx = utils.Max(0, m.currentChapterIdx())
+			}
+		case keySearchResults:
+			if m.currentTab == 0 && len(m.searchResults) > 0 {
+				m.showResultsDialog = true
+				m.currentResultIdx = m.currentSearchIdx
+			}
+		case keyLibrarySearch:
+			if m.currentTab == 0 {
+				m.showLibraryDialog = true
+				m.libraryInput = ""
+				m.libraryHits = nil
+				m.libraryStatus = ""
+			}
 		case keyOpenLinksDialog:
 			m.showLinksDialog = true
 			m.currentLinkIdx = 0
@@ -585,10 +977,12 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case keyLeft:
 					if len(palabras) > 0 {
 						m.currentWordIdx = (m.currentWordIdx - 1 + len(palabras)) % len(palabras)
+						m.syncViewportOffset() // The word may be on another visual row
 					}
 				case keyRight:
 					if len(palabras) > 0 {
 						m.currentWordIdx = (m.currentWordIdx + 1) % len(palabras)
+						m.syncViewportOffset()
 					}
 				case keyAddToVocabulary:
 					if len(palabras) > 0 && m.currentWordIdx < len(palabras) {
@@ -618,20 +1012,35 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case keyZero:
 					if len(palabras) > 0 {
 						m.currentWordIdx = 0
+						m.syncViewportOffset()
 					}
 				case keyDollarSign:
 					if len(palabras) > 0 {
 						m.currentWordIdx = len(palabras) - 1
+						m.syncViewportOffset()
+					}
+				case keyNarrowerText:
+					m.textWidth = utils.Max(minTextWidth, m.columnWidth()-textWidthStep)
+					m.syncViewportOffset()
+				case keyWiderText:
+					// No wider than the terminal, but never narrower than the current column.
+					m.textWidth = utils.Max(m.textWidth, utils.Min(m.vp.Width, m.textWidth+textWidthStep))
+					m.syncViewportOffset()
+				default:
+					// Paging keys of the viewport (pgup/pgdn, space, b, u, d) move
+					// the current line by visual rows.
+					switch {
+					case key.Matches(msg, m.vp.KeyMap.PageDown):
+						m.scrollRows(m.vp.Height)
+					case key.Matches(msg, m.vp.KeyMap.PageUp):
+						m.scrollRows(-m.vp.Height)
+					case key.Matches(msg, m.vp.KeyMap.HalfPageDown):
+						m.scrollRows(m.vp.Height / 2)
+					case key.Matches(msg, m.vp.KeyMap.HalfPageUp):
+						m.scrollRows(-m.vp.Height / 2)
 					}
 				}
-
-				// Delegates to viewport.Update for default keys like pgup/pgdn (not for "j/k" since you handle them manually)
-				var cmd tea.Cmd
-				m.vp, cmd = m.vp.Update(msg)
-				// Actualiza currentLine si YOffset cambió (ej. por pgup)
-				halfHeight := m.vp.Height / 2
-				m.currentLine = utils.Max(0, utils.Min(len(m.lines)-1, m.vp.YOffset+halfHeight))
-				return m, cmd
+				return m, nil
 			} else if m.currentTab == 1 {
 				switch msg.String() {
 				case keyNextLine, "down":
@@ -708,13 +1117,15 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.MouseMsg:
 		if m.currentTab == 0 {
-			// Delega a viewport para wheel up/down
-			var cmd tea.Cmd
-			m.vp, cmd = m.vp.Update(msg)
-			// Actualiza currentLine al centro visible si YOffset cambió
-			halfHeight := m.vp.Height / 2
-			m.currentLine = utils.Max(0, utils.Min(len(m.lines)-1, m.vp.YOffset+halfHeight))
-			return m, cmd
+			if m.vp.MouseWheelEnabled && msg.Action == tea.MouseActionPress {
+				switch msg.Button {
+				case tea.MouseButtonWheelUp:
+					m.scrollRows(-m.vp.MouseWheelDelta)
+				case tea.MouseButtonWheelDown:
+					m.scrollRows(m.vp.MouseWheelDelta)
+				}
+			}
+			return m, nil
 		} else if m.currentTab == 1 {
 			var cmd tea.Cmd
 			m.vocabVP, cmd = m.vocabVP.Update(msg)
@@ -747,11 +1158,119 @@ func (m UiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// syncViewportOffset scrolls the Texto tab so the visual row holding the
+// selected word is centered, without scrolling past either end of the text.
 func (m *UiModel) syncViewportOffset() {
-	halfHeight := m.vp.Height / 2
-	newOffset := utils.Max(0, m.currentLine-halfHeight)
-	maxOffset := utils.Max(0, len(m.lines)-m.vp.Height)
-	m.vp.YOffset = utils.Min(newOffset, maxOffset)
+	line, row := m.currentLine, m.currentRow()
+	for n := m.vp.Height / 2; n > 0 && (line > 0 || row > 0); n-- {
+		line, row = m.prevRow(line, row)
+	}
+	// Near the end, scroll back up until the rows below fill the viewport.
+	for below := m.rowsFrom(line, row, m.vp.Height); below < m.vp.Height && (line > 0 || row > 0); below++ {
+		line, row = m.prevRow(line, row)
+	}
+	m.topLine, m.topRow = line, row
+}
+
+// scrollRows moves the selection n visual rows down (or up when negative),
+// selecting the first word of the row it lands on.
+func (m *UiModel) scrollRows(n int) {
+	line, row := m.currentLine, m.currentRow()
+	for ; n > 0; n-- {
+		if row < len(m.wrapLine(line))-1 {
+			row++
+		} else if line < len(m.lines)-1 {
+			line, row = line+1, 0
+		} else {
+			break
+		}
+	}
+	for ; n < 0 && (line > 0 || row > 0); n++ {
+		line, row = m.prevRow(line, row)
+	}
+
+	m.currentLine = line
+	m.currentWordIdx = 0
+	start := m.wrapLine(line)[row].Start
+	for j, word := range text.WordSpans(m.lines[line]) {
+		if word[1] > start {
+			m.currentWordIdx = j
+			break
+		}
+	}
+	m.syncViewportOffset()
+}
+
+// columnWidth is the width of the reading column of the Texto tab, centered
+// between the margins when the terminal is wider than textWidth.
+func (m UiModel) columnWidth() int {
+	return utils.Min(m.vp.Width, m.textWidth)
+}
+
+// wrapLine splits line i into the visual rows it takes in the Texto tab. Room
+// is left for the padding of the current line and word, and for the border of
+// quotes, so a line wraps the same way whether it is selected or not.
+func (m UiModel) wrapLine(i int) []text.Row {
+	width := m.columnWidth() - 4
+	if m.lineKinds[i] == document.LineQuote {
+		width -= 2
+	}
+	if m.vp.Width <= 0 {
+		width = 0 // Not laid out yet
+	}
+	return text.Wrap(m.lines[i], width)
+}
+
+// currentRow is the visual row of the current line holding the selected word.
+func (m UiModel) currentRow() int {
+	words := text.WordSpans(m.lines[m.currentLine])
+	if m.currentWordIdx >= len(words) {
+		return 0
+	}
+	return text.RowOf(m.wrapLine(m.currentLine), words[m.currentWordIdx][0])
+}
+
+// prevRow returns the visual row before row of line, which must not be the
+// first row of the text.
+func (m UiModel) prevRow(line, row int) (int, int) {
+	if row > 0 {
+		return line, row - 1
+	}
+	return line - 1, len(m.wrapLine(line-1)) - 1
+}
+
+// rowsFrom counts the visual rows from row of line to the end of the text,
+// stopping at limit.
+func (m UiModel) rowsFrom(line, row, limit int) int {
+	n := -row
+	for i := line; i < len(m.lines) && n < limit; i++ {
+		n += len(m.wrapLine(i))
+	}
+	return utils.Min(n, limit)
+}
+
+// visualRow is a row of the Texto tab: part of line, or all of it.
+type visualRow struct {
+	line int
+	text.Row
+}
+
+// visibleRows lays out the rows shown in the Texto tab, from the top of the
+// viewport down.
+func (m UiModel) visibleRows() []visualRow {
+	var rows []visualRow
+	skip := m.topRow
+	for i := m.topLine; i < len(m.lines) && len(rows) < m.vp.Height; i++ {
+		wrapped := m.wrapLine(i)
+		for _, row := range wrapped[utils.Min(skip, len(wrapped)-1):] {
+			if len(rows) == m.vp.Height {
+				break
+			}
+			rows = append(rows, visualRow{line: i, Row: row})
+		}
+		skip = 0
+	}
+	return rows
 }
 
 func (m UiModel) View() string {
@@ -764,6 +1283,15 @@ func (m UiModel) View() string {
 	if m.showGotoLineDialog {
 		return m.renderWithDialog(m.renderGoToLineDialog())
 	}
+	if m.showTOCDialog {
+		return m.renderWithDialog(m.renderTOCDialog())
+	}
+	if m.showResultsDialog {
+		return m.renderWithDialog(m.renderResultsDialog())
+	}
+	if m.showLibraryDialog {
+		return m.renderWithDialog(m.renderLibraryDialog())
+	}
 	if m.showNoteDialog {
 		return m.renderWithDialog(m.renderNoteDialog())
 	}
@@ -817,6 +1345,15 @@ func (m UiModel) renderMainContent() string {
 
 	// File label
 	fileName := filepath.Base(m.filePath)
+	if m.filePath == document.StdinPath {
+		fileName = "(entrada estándar)"
+	}
+	if m.metadata.Title != "" {
+		fileName = m.metadata.Title
+		if m.metadata.Author != "" {
+			fileName += " — " + m.metadata.Author
+		}
+	}
 	fileLabel := lipgloss.NewStyle().
 		Foreground(cyanColor).
 		Background(darkGrayColor).
@@ -833,28 +1370,44 @@ func (m UiModel) renderMainContent() string {
 	}
 
 	if m.currentTab == 0 {
-		// Texto tab: show lines around current
-		viewStart := m.vp.YOffset                                 // Usa offset del viewport
-		viewEnd := utils.Min(len(m.lines), viewStart+m.vp.Height) // Visible height
-		for i := viewStart; i < viewEnd; i++ {
-			if i == m.currentLine {
+		// Texto tab: show the visual rows around the current line
+		margin := strings.Repeat(" ", utils.Max(0, (m.width-m.columnWidth())/2))
+		for _, row := range m.visibleRows() {
+			content.WriteString(margin)
+			if row.line == m.currentLine {
 				// Highlight current line and word
-				line := m.lines[i]
-				words := strings.Fields(line)
-				var highlightedWords []string
-				for j, word := range words {
-					if j == m.currentWordIdx && len(words) > 0 {
-						highlightedWords = append(highlightedWords, lipgloss.NewStyle().
+				line := m.lines[row.line]
+				marks := m.matchMarks(row.line, row.Row)
+				if words := text.WordSpans(line); m.currentWordIdx < len(words) {
+					// A word longer than a row is split, highlight each part.
+					start := utils.Max(row.Start, words[m.currentWordIdx][0])
+					end := utils.Min(row.End, words[m.currentWordIdx][1])
+					for j := start; j < end; j++ {
+						marks[j-row.Start] = markWord
+					}
+				}
+				var sb strings.Builder
+				for start := 0; start < len(marks); {
+					end := start
+					for end < len(marks) && marks[end] == marks[start] {
+						end++
+					}
+					segment := line[row.Start+start : row.Start+end]
+					switch marks[start] {
+					case markWord:
+						segment = lipgloss.NewStyle().
 							Bold(true).
 							Background(brightYellowColor).
 							Foreground(greyColor).
 							Padding(0, 1).
-							Render(word))
-					} else {
-						highlightedWords = append(highlightedWords, word)
+							Render(segment)
+					case markMatch, markCurrentMatch:
+						segment = matchStyle(lipgloss.NewStyle(), marks[start] == markCurrentMatch).Render(segment)
 					}
+					sb.WriteString(segment)
+					start = end
 				}
-				hlLine := strings.Join(highlightedWords, " ")
+				hlLine := sb.String()
 				// Apply line highlight
 				hlLine = lipgloss.NewStyle().
 					Background(darkGrayColor).
@@ -863,9 +1416,7 @@ func (m UiModel) renderMainContent() string {
 					Render(hlLine)
 				content.WriteString(hlLine + "\n")
 			} else {
-				content.WriteString(lipgloss.NewStyle().
-					Foreground(lightGrayColor). // Light gray for non-current lines
-					Render(m.lines[i]) + "\n")
+				content.WriteString(m.renderLine(row.line, row.Row) + "\n")
 			}
 		}
 	} else if m.currentTab == 1 {
@@ -955,6 +1506,12 @@ func (m UiModel) renderMainContent() string {
 			"Palabra más larga: " + boldStyle.Render(m.longestWord),
 			"Velocidad de lectura: " + boldStyle.Render(fmt.Sprintf("%.0f WPM", wpm)),
 		}
+		if !m.statsReady {
+			// Counts so far, refreshed while the rest of the text is counted.
+			statsLines = append([]string{
+				italicStyle.Render(fmt.Sprintf("Calculando… %.0f%%", m.statsProgress*100)),
+			}, statsLines...)
+		}
 		if len(m.topWords) > 0 {
 			statsLines = append(statsLines, "Top palabras frecuentes:")
 			for i, wc := range m.topWords {
@@ -974,16 +1531,41 @@ func (m UiModel) renderMainContent() string {
 	// Status bar
 	total := len(m.lines)
 	percent := float64(0)
-	if total > 0 {
+	if total > 1 {
 		percent = float64(m.currentLine) / float64(total-1) * 100
 	}
 	lineInfo := fmt.Sprintf("Línea: %d/%d (%.4f%%)", m.currentLine+1, total, percent)
 
+	chapterInfo := ""
+	if idx := m.currentChapterIdx(); idx >= 0 {
+		start := m.chapters[idx].Line
+		end := total
+		// Nested entries share lines with their parents, so look for the next
+		// chapter that actually starts further down.
+		for _, ch := range m.chapters[idx+1:] {
+			if ch.Line > start {
+				end = ch.Line
+				break
+			}
+		}
+		chapterPercent := float64(100)
+		if end-start > 1 {
+			chapterPercent = float64(m.currentLine-start) / float64(end-start-1) * 100
+		}
+		chapterInfo = fmt.Sprintf(" | Capítulo: %s (%.0f%%)", m.chapters[idx].Title, chapterPercent)
+	}
+
+	cueInfo := ""
+	if idx := m.currentCueIdx(); idx >= 0 {
+		cueInfo = fmt.Sprintf(" | Tiempo: %s → %s",
+			formatTimestamp(m.cues[idx].Start), formatTimestamp(m.cues[idx].End))
+	}
+
 	// Mostrar información de búsqueda si hay resultados activos
 	searchInfo := ""
 	if len(m.searchResults) > 0 {
-		searchInfo = fmt.Sprintf(" | Búsqueda: %d/%d resultados para '%s'",
-			m.currentSearchIdx+1, len(m.searchResults), m.searchTerm)
+		searchInfo = fmt.Sprintf(" | Búsqueda: %d/%d resultados para %s",
+			m.currentSearchIdx+1, len(m.searchResults), describeSearch(m.searchMatcher))
 	}
 
 	selInfo := ""
@@ -996,8 +1578,14 @@ func (m UiModel) renderMainContent() string {
 	} else if m.currentTab == 2 && len(m.notes) > 0 {
 		selInfo = fmt.Sprintf(" | Nota: %d/%d", m.currentNoteIdx+1, len(m.notes))
 	}
+	// Cuántas veces aparece la palabra resaltada, según el índice
+	if word := m.highlightedWord(); m.wordIndex != nil && word != "" {
+		if count := m.wordIndex.Count(word); count > 0 {
+			selInfo += fmt.Sprintf(" | Apariciones: %d", count)
+		}
+	}
 	timeLeft := m.remainingTimeString()
-	status := lineInfo + searchInfo + selInfo + " | Tiempo restante: " + timeLeft
+	status := lineInfo + chapterInfo + cueInfo + searchInfo + selInfo + " | Tiempo restante: " + timeLeft
 	//status := lineInfo + selInfo + " | Tiempo restante: " + timeLeft
 	statusStyle := lipgloss.NewStyle().
 		Padding(0, 1).
@@ -1012,42 +1600,778 @@ func (m UiModel) renderMainContent() string {
 	return content.String()
 }
 
-func (m UiModel) getCurrentWPM() float64 {
-	totalSec := m.totalReadingSeconds + m.sessionReadingTime
-	totalWords := m.totalReadWords + m.sessionWordsRead
-	if totalSec > 0 && totalWords > 0 {
-		return float64(totalWords) / (totalSec / 60)
+// lineStyle returns the text style of a non-current line in the Texto tab.
+func (m UiModel) lineStyle(i int) lipgloss.Style {
+	style := lipgloss.NewStyle().
+		Foreground(lightGrayColor) // Light gray for non-current lines
+	switch m.lineKinds[i] {
+	case document.LineHeading:
+		style = style.
+			Bold(true).
+			Foreground(cyanColor)
+	case document.LineQuote:
+		style = style.
+			Italic(true).
+			Foreground(mediumGrayColor)
+	case document.LineCode:
+		style = style.
+			Foreground(brightWhiteColor).
+			Background(greyColor)
 	}
-	return DefaultWPM
+	return style
 }
 
-func (m UiModel) remainingTimeString() string {
-	wpm := m.getCurrentWPM()
-	wordsRead := m.cumulativeWords[m.currentLine] + m.sessionWordsRead // Include session progress
-	wordsLeft := m.totalWords - wordsRead
-	if wordsLeft <= 0 {
-		return "0m"
+// renderLine renders a visual row of a non-current line of the Texto tab,
+// applying the style of its kind and any inline spans.
+func (m UiModel) renderLine(i int, row text.Row) string {
+	line := m.lines[i][row.Start:row.End]
+	style := m.lineStyle(i)
+	spans := m.lineSpans[i]
+	marks := m.matchMarks(i, row)
+
+	var rendered string
+	if len(spans) == 0 && !slices.ContainsFunc(marks, func(mark rowMark) bool { return mark != markNone }) {
+		rendered = style.Render(line)
+	} else {
+		// Resolve the style flags of every byte, then render runs of equal flags.
+		flags := make([]document.SpanStyle, len(line))
+		for _, span := range spans {
+			for j := utils.Max(0, span.Start-row.Start); j < utils.Min(len(line), span.End-row.Start); j++ {
+				flags[j] |= span.Style
+			}
+		}
+		var sb strings.Builder
+		for start := 0; start < len(line); {
+			end := start
+			for end < len(line) && flags[end] == flags[start] && marks[end] == marks[start] {
+				end++
+			}
+			runStyle := spanStyle(style, flags[start])
+			if marks[start] != markNone {
+				runStyle = matchStyle(runStyle, marks[start] == markCurrentMatch)
+			}
+			sb.WriteString(runStyle.Render(line[start:end]))
+			start = end
+		}
+		rendered = sb.String()
 	}
-	minutesLeft := float64(wordsLeft) / wpm
-	hours := int(minutesLeft / 60)
-	mins := int(minutesLeft) % 60
-	if hours > 0 {
-		return fmt.Sprintf("%dh %dm", hours, mins)
+
+	if m.lineKinds[i] == document.LineQuote {
+		rendered = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(mediumGrayColor).
+			PaddingLeft(1).
+			Render(rendered)
 	}
-	return fmt.Sprintf("%dm", mins)
+	return rendered
 }
 
-func (m UiModel) renderGoToLineDialog() string {
-	dialogContent := fmt.Sprintf("Go to line: %s", m.lineInput)
+// rowMark tells how a byte of a row of the Texto tab is highlighted.
+type rowMark uint8
 
-	dialog := lipgloss.NewStyle().
-		Width(30).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(royalBlueColor).
-		Padding(1, 2).
-		Align(lipgloss.Center).
-		Background(greyColor).
-		Render(dialogContent)
+const (
+	markNone rowMark = iota
+	markMatch
+	markCurrentMatch
+	markWord // Selected word of the current line
+)
+
+// matchMarks marks the bytes of a row of line i that are part of a match of
+// the last search.
+func (m UiModel) matchMarks(i int, row text.Row) []rowMark {
+	marks := make([]rowMark, row.End-row.Start)
+	matches := m.lineMatches(i)
+	if len(matches) == 0 {
+		return marks
+	}
+	current, ok := m.currentMatch()
+	if m.showSearchDialog && m.searchPreview != nil {
+		// The current match belongs to the previous search
+		ok = false
+	}
+	for _, match := range matches {
+		mark := markMatch
+		if ok && current.Line == i && current.Start == match[0] {
+			mark = markCurrentMatch
+		}
+		for j := utils.Max(row.Start, match[0]); j < utils.Min(row.End, match[1]); j++ {
+			marks[j-row.Start] = mark
+		}
+	}
+	return marks
+}
+
+func spanStyle(style lipgloss.Style, flags document.SpanStyle) lipgloss.Style {
+	if flags&document.SpanEmphasis != 0 {
+		style = style.Italic(true)
+	}
+	if flags&document.SpanStrong != 0 {
+		style = style.
+			Bold(true).
+			Foreground(brightWhiteColor)
+	}
+	if flags&document.SpanCode != 0 {
+		style = style.
+			Foreground(brightYellowColor).
+			Background(greyColor)
+	}
+	return style
+}
+
+// progressFileName is the path the current document is reopened from, absolute
+// so the reading library can open it from any directory.
+func (m UiModel) progressFileName() string {
+	if m.filePath == document.StdinPath {
+		return m.filePath
+	}
+	return absPath(m.filePath)
+}
+
+// absPath returns the absolute form of path, or path itself if it cannot be
+// resolved.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// gutenbergLine translates line of a book to the layout with its Gutenberg
+// boilerplate stripped, or back when cleaned is false. starts are the lines
+// of the original each cleaned line starts at.
+func gutenbergLine(line int, starts []int, cleaned bool) int {
+	if !cleaned {
+		if line < len(starts) {
+			return starts[line]
+		}
+		return line
+	}
+	// The paragraph the line was joined into
+	i, found := slices.BinarySearch(starts, line)
+	if !found {
+		i--
+	}
+	return utils.Max(0, i)
+}
+
+// progressEntry collects the state persisted for the current file.
+func (m UiModel) progressEntry() model.ProgressEntry {
+	return model.ProgressEntry{
+		FileName:       m.progressFileName(),
+		Title:          m.metadata.Title,
+		Author:         m.metadata.Author,
+		Line:           m.currentLine,
+		Vocabulary:     m.vocabulary,
+		Notes:          m.notes,
+		ReadingSeconds: m.totalReadingSeconds,
+		ReadWords:      m.totalReadWords,
+		Gutenberg:      m.gutenberg,
+		TextWidth:      m.textWidth,
+		Encoding:       m.encoding,
+	}
+}
+
+func (m UiModel) getCurrentWPM() float64 {
+	totalSec := m.totalReadingSeconds + m.sessionReadingTime
+	totalWords := m.totalReadWords + m.sessionWordsRead
+	if totalSec > 0 && totalWords > 0 {
+		return float64(totalWords) / (totalSec / 60)
+	}
+	return DefaultWPM
+}
+
+// highlightedWord returns the word under the cursor in the text tab, or the
+// selected word in the vocabulary tab.
+func (m UiModel) highlightedWord() string {
+	switch {
+	case m.currentTab == 0 && m.currentLine < len(m.lines):
+		words := strings.Fields(m.lines[m.currentLine])
+		if m.currentWordIdx < len(words) {
+			return words[m.currentWordIdx]
+		}
+	case m.currentTab == 1 && len(m.vocabulary) > 0:
+		return m.vocabulary[m.currentVocabIdx]
+	}
+	return ""
+}
+
+func (m UiModel) remainingTimeString() string {
+	if !m.statsReady {
+		return "calculando…"
+	}
+	wpm := m.getCurrentWPM()
+	wordsRead := m.cumulativeWords[m.currentLine] + m.sessionWordsRead // Include session progress
+	wordsLeft := m.totalWords - wordsRead
+	if wordsLeft <= 0 {
+		return "0m"
+	}
+	minutesLeft := float64(wordsLeft) / wpm
+	hours := int(minutesLeft / 60)
+	mins := int(minutesLeft) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
+
+func (m UiModel) renderGoToLineDialog() string {
+	dialogContent := fmt.Sprintf("Go to line: %s", m.lineInput)
+	width := 30
+	if len(m.cues) > 0 {
+		dialogContent = fmt.Sprintf("Go to line or time (hh:mm:ss): %s", m.lineInput)
+		width = 50
+	}
+
+	dialog := lipgloss.NewStyle().
+		Width(width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(royalBlueColor).
+		Padding(1, 2).
+		Align(lipgloss.Center).
+		Background(greyColor).
+		Render(dialogContent)
+
+	return dialog
+}
+
+// currentCueIdx returns the index of the subtitle the current line belongs
+// to, or -1 if there is none.
+func (m UiModel) currentCueIdx() int {
+	idx := -1
+	for i, cue := range m.cues {
+		if cue.Line > m.currentLine {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+// formatTimestamp formats a subtitle time as hh:mm:ss.
+func formatTimestamp(d time.Duration) string {
+	seconds := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// currentChapterIdx returns the index of the chapter containing the current
+// line, or -1 if the document has no chapters or the line is before the first.
+func (m UiModel) currentChapterIdx() int {
+	idx := -1
+	for i, ch := range m.chapters {
+		if ch.Line > m.currentLine {
+			break
+		}
+		idx = i
+	}
+	return idx
+}
+
+func (m UiModel) renderTOCDialog() string {
+	dialogWidth := utils.Min(m.width-4, 60)
+
+	title := lipgloss.NewStyle().
+		Foreground(brightWhiteColor).
+		Align(lipgloss.Center).
+		Padding(0, 0).
+		Width(dialogWidth - 4).
+		Render("Tabla de contenidos")
+
+	// Only show a window of entries around the selection so long TOCs fit.
+	viewStart, viewEnd := listWindow(m.currentTOCIdx, len(m.chapters), utils.Max(1, m.height-14))
+
+	var items []string
+	for i := viewStart; i < viewEnd; i++ {
+		ch := m.chapters[i]
+		style := lipgloss.NewStyle().
+			Width(dialogWidth-6).
+			Padding(0, 1).
+			Align(lipgloss.Left)
+		if i == m.currentTOCIdx {
+			style = style.
+				Background(darkGrayColor).
+				Foreground(brightWhiteColor)
+		} else {
+			style = style.Foreground(lightGrayColor)
+		}
+		entry := strings.Repeat("  ", ch.Level) + ch.Title
+		items = append(items, style.Render(fmt.Sprintf("%s (%d)", entry, ch.Line+1)))
+	}
+	list := lipgloss.JoinVertical(lipgloss.Left, items...)
+
+	listBox := lipgloss.NewStyle().
+		Width(dialogWidth-4).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(royalBlueColor).
+		Padding(0, 1).
+		Render(list)
+
+	hint := lipgloss.NewStyle().
+		Foreground(mediumGrayColor).
+		Align(lipgloss.Center).
+		Width(dialogWidth - 4).
+		Render("j/k para navegar | Enter para ir | Esc para cerrar")
+
+	dialogContent := lipgloss.JoinVertical(lipgloss.Left, title, listBox, hint)
+
+	dialog := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(royalBlueColor).
+		Padding(1).
+		Background(greyColor).
+		Render(dialogContent)
+
+	return dialog
+}
+
+// listWindow returns the range of the total entries of a dialog list to show,
+// at most size of them, keeping the selected one in the middle when possible.
+func listWindow(selected, total, size int) (start, end int) {
+	start = utils.Max(0, selected-size/2)
+	end = utils.Min(total, start+size)
+	start = utils.Max(0, end-size)
+	return start, end
+}
+
+// resultsPageSize is the number of results the results dialog shows at once.
+func (m UiModel) resultsPageSize() int {
+	return utils.Max(1, m.height-14)
+}
+
+func (m UiModel) renderResultsDialog() string {
+	dialogWidth := utils.Min(m.width-4, 80)
+
+	title := lipgloss.NewStyle().
+		Foreground(brightWhiteColor).
+		Align(lipgloss.Center).
+		Padding(0, 0).
+		Width(dialogWidth - 4).
+		Render(fmt.Sprintf("%d resultados para %s", len(m.searchResults), describeSearch(m.searchMatcher)))
+
+	// Only show a window of results around the selection, there may be
+	// thousands of them.
+	viewStart, viewEnd := listWindow(m.currentResultIdx, len(m.searchResults), m.resultsPageSize())
+
+	numberWidth := len(strconv.Itoa(len(m.lines)))
+	var items []string
+	for i := viewStart; i < viewEnd; i++ {
+		style := lipgloss.NewStyle().
+			Width(dialogWidth-6).
+			Padding(0, 1).
+			Align(lipgloss.Left)
+		if i == m.currentResultIdx {
+			style = style.
+				Background(darkGrayColor).
+				Foreground(brightWhiteColor)
+		} else {
+			style = style.Foreground(lightGrayColor)
+		}
+		line := m.searchResults[i]
+		number := fmt.Sprintf("%*d  ", numberWidth, line+1)
+		snippet := matchSnippet(m.lines[line], m.searchMatcher, dialogWidth-8-len(number))
+		items = append(items, style.Render(number+snippet))
+	}
+	list := lipgloss.JoinVertical(lipgloss.Left, items...)
+
+	listBox := lipgloss.NewStyle().
+		Width(dialogWidth-4).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(royalBlueColor).
+		Padding(0, 1).
+		Render(list)
+
+	hint := lipgloss.NewStyle().
+		Foreground(mediumGrayColor).
+		Align(lipgloss.Center).
+		Width(dialogWidth - 4).
+		Render("j/k para navegar | PgUp/PgDn | Enter para ir | Esc para cerrar")
+
+	dialogContent := lipgloss.JoinVertical(lipgloss.Left, title, listBox, hint)
+
+	dialog := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(royalBlueColor).
+		Padding(1).
+		Background(greyColor).
+		Render(dialogContent)
+
+	return dialog
+}
+
+// matchSnippet returns the part of line around the first match of matcher
+// that fits in width columns, with the match highlighted. Cut ends are marked
+// with "…".
+func matchSnippet(line string, matcher *search.Matcher, width int) string {
+	line = strings.ReplaceAll(line, "\t", " ")
+	start, end := 0, 0
+	if matches := matcher.FindAll(line); len(matches) > 0 {
+		start, end = matches[0][0], matches[0][1]
+	}
+
+	// Give the context before the match half of the room left, or more if
+	// the rest of the line is short. Two columns are kept for the "…".
+	room := width - 2 - text.StringWidth(line[start:end])
+	before := utils.Max(room/2, room-text.StringWidth(line[end:]))
+	after := room
+	from := start
+	for from > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:from])
+		if text.RuneWidth(r) > before {
+			break
+		}
+		before -= text.RuneWidth(r)
+		after -= text.RuneWidth(r)
+		from -= size
+	}
+	to := end
+	for to < len(line) {
+		r, size := utf8.DecodeRuneInString(line[to:])
+		if text.RuneWidth(r) > after {
+			break
+		}
+		after -= text.RuneWidth(r)
+		to += size
+	}
+
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = "…"
+	}
+	if to < len(line) {
+		suffix = "…"
+	}
+	if start == end {
+		return prefix + line[from:to] + suffix
+	}
+	return prefix + line[from:start] +
+		matchStyle(lipgloss.NewStyle(), false).Render(line[start:end]) +
+		line[end:to] + suffix
+}
+
+// maxLibraryHits caps the matches a library search collects.
+const maxLibraryHits = 500
+
+// libraryHit is a match of a library search.
+type libraryHit struct {
+	path     string // Path the document is opened from
+	encoding string // Encoding forced for the document, if any
+	name     string // Title of the document, or its file name
+	chapter  string
+	line     int
+	text     string
+}
+
+// libraryDoc is a document of the reading library.
+type libraryDoc struct {
+	path      string
+	gutenberg bool
+	encoding  string
+}
+
+// libraryResultsMsg delivers the matches of a library search.
+type libraryResultsMsg struct {
+	id       int
+	hits     []libraryHit
+	searched int // Documents searched
+	failed   int // Documents that could not be opened
+}
+
+// libraryDocs returns the documents whose progress is stored, which are the
+// ones opened so far, starting with the one being read.
+func (m UiModel) libraryDocs() ([]libraryDoc, error) {
+	entries, err := progress.All()
+	if err != nil {
+		return nil, err
+	}
+	sorted := slices.SortedFunc(maps.Values(entries), func(a, b model.ProgressEntry) int {
+		return strings.Compare(a.FileName, b.FileName)
+	})
+
+	var docs []libraryDoc
+	seen := make(map[string]bool)
+	add := func(path string, gutenberg bool, encoding string) {
+		if path == "" || path == document.StdinPath {
+			return
+		}
+		// Earlier versions stored the path as typed, maybe relative.
+		path = absPath(path)
+		if !seen[path] {
+			seen[path] = true
+			docs = append(docs, libraryDoc{path: path, gutenberg: gutenberg, encoding: encoding})
+		}
+	}
+	add(m.filePath, m.gutenberg, m.encoding)
+	for _, entry := range sorted {
+		add(entry.FileName, entry.Gutenberg, entry.Encoding)
+	}
+	return docs, nil
+}
+
+// searchLibrary looks for the matches of matcher in every document of docs,
+// loading each with its loader like when it is read. Documents that cannot be
+// opened any more are skipped.
+func searchLibrary(id int, matcher *search.Matcher, docs []libraryDoc) tea.Cmd {
+	return func() tea.Msg {
+		msg := libraryResultsMsg{id: id}
+		for _, d := range docs {
+			if len(msg.hits) == maxLibraryHits {
+				break
+			}
+			doc, err := document.Open(d.path, document.Options{Encoding: d.encoding})
+			if err != nil {
+				msg.failed++
+				continue
+			}
+			if d.gutenberg {
+				document.CleanGutenberg(doc)
+			}
+			msg.searched++
+
+			name := doc.Metadata.Title
+			if name == "" {
+				name = filepath.Base(d.path)
+			}
+			chapter := -1
+			for i, line := range doc.Lines {
+				for chapter+1 < len(doc.Chapters) && doc.Chapters[chapter+1].Line <= i {
+					chapter++
+				}
+				if !matcher.Match(line) {
+					continue
+				}
+				// Lines of large files point into memory released by Close.
+				hit := libraryHit{path: d.path, encoding: d.encoding, name: strings.Clone(name), line: i, text: strings.Clone(line)}
+				if chapter >= 0 {
+					hit.chapter = strings.Clone(doc.Chapters[chapter].Title)
+				}
+				msg.hits = append(msg.hits, hit)
+				if len(msg.hits) == maxLibraryHits {
+					break
+				}
+			}
+			doc.Close()
+		}
+		return msg
+	}
+}
+
+func (m UiModel) updateLibraryDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc, keyCancel:
+		m.showLibraryDialog = false
+		m.librarySearching = false
+		m.librarySearchID++ // Drop the results of a search still running
+		return m, nil
+	}
+
+	if m.libraryHits != nil {
+		// Browsing the matches
+		switch msg.String() {
+		case keyNextLine, "down":
+			if m.currentLibraryIdx < len(m.libraryHits)-1 {
+				m.currentLibraryIdx++
+			}
+		case keyPrevLine, "up":
+			if m.currentLibraryIdx > 0 {
+				m.currentLibraryIdx--
+			}
+		case "pgdown":
+			m.currentLibraryIdx = utils.Min(len(m.libraryHits)-1, m.currentLibraryIdx+m.libraryPageSize())
+		case "pgup":
+			m.currentLibraryIdx = utils.Max(0, m.currentLibraryIdx-m.libraryPageSize())
+		case keyEnter:
+			if len(m.libraryHits) > 0 {
+				return m.openLibraryHit(m.libraryHits[m.currentLibraryIdx])
+			}
+		}
+		return m, nil
+	}
+	if m.librarySearching {
+		return m, nil
+	}
+
+	// Typing the term
+	switch msg.String() {
+	case keyEnter:
+		if m.libraryInput == "" {
+			return m, nil
+		}
+		matcher, err := search.New(m.libraryInput, m.searchOptions)
+		if err != nil {
+			m.libraryStatus = "Patrón no válido: " + err.Error()
+			return m, nil
+		}
+		docs, err := m.libraryDocs()
+		if err != nil {
+			m.libraryStatus = err.Error()
+			return m, nil
+		}
+		m.librarySearchID++
+		m.librarySearching = true
+		m.libraryMatcher = matcher
+		m.libraryStatus = fmt.Sprintf("Buscando en %d documentos…", len(docs))
+		return m, searchLibrary(m.librarySearchID, matcher, docs)
+	case keyBackspace:
+		if len(m.libraryInput) > 0 {
+			_, size := utf8.DecodeLastRuneInString(m.libraryInput)
+			m.libraryInput = m.libraryInput[:len(m.libraryInput)-size]
+		}
+		m.libraryStatus = ""
+	default:
+		if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
+			m.libraryInput += string(msg.Runes)
+			m.libraryStatus = ""
+		}
+	}
+	return m, nil
+}
+
+// openLibraryHit moves the cursor to a match of the library search, opening
+// its document first if it is not the one being read. The progress of the
+// document left is saved and its memory released.
+func (m UiModel) openLibraryHit(hit libraryHit) (tea.Model, tea.Cmd) {
+	m.showLibraryDialog = false
+	if hit.path == absPath(m.filePath) {
+		m.jumpToMatch(hit.line, m.libraryMatcher)
+		m.lastActionTime = time.Now() // Reset action time after jump
+		return m, nil
+	}
+
+	next, err := InitialModel(hit.path, Options{Encoding: hit.encoding})
+	if err != nil {
+		m.showLibraryDialog = true
+		m.libraryStatus = err.Error()
+		return m, nil
+	}
+
+	m.cancelStats()
+	m.totalReadingSeconds += m.sessionReadingTime
+	m.totalReadWords += m.sessionWordsRead
+	if m.filePath != "" {
+		if err := progress.Save(m.docID, m.progressEntry()); err != nil {
+			fmt.Printf("Error saving progress: %v\n", err)
+		}
+	}
+	// The statistics of the document left may still be counting its lines,
+	// so its memory is released once they stop.
+	doc, updates := m.doc, m.statsUpdates
+	release := func() tea.Msg {
+		for range updates {
+		}
+		if doc != nil {
+			doc.Close()
+		}
+		return nil
+	}
+
+	// The new model has not been sized yet, it centers the match once it is.
+	next.searchOptions = m.searchOptions
+	next.jumpToMatch(hit.line, m.libraryMatcher)
+	width, height := m.width, m.height
+	return next, tea.Batch(next.Init(), release, func() tea.Msg {
+		return tea.WindowSizeMsg{Width: width, Height: height}
+	})
+}
+
+// jumpToMatch moves the cursor to the word of line where matcher first
+// matches.
+func (m *UiModel) jumpToMatch(line int, matcher *search.Matcher) {
+	m.currentLine = utils.Min(len(m.lines)-1, line)
+	m.currentWordIdx = 0
+	if matches := matcher.FindAll(m.lines[m.currentLine]); len(matches) > 0 {
+		m.currentWordIdx = wordAt(m.lines[m.currentLine], matches[0][0])
+	}
+	m.syncViewportOffset()
+}
+
+// libraryPageSize is the number of matches the library dialog shows at once.
+func (m UiModel) libraryPageSize() int {
+	return utils.Max(1, (m.height-16)/2)
+}
+
+func (m UiModel) renderLibraryDialog() string {
+	dialogWidth := utils.Min(m.width-4, 80)
+
+	title := lipgloss.NewStyle().
+		Foreground(brightWhiteColor).
+		Background(blueColor).
+		Bold(true).
+		Align(lipgloss.Center).
+		Padding(0, 1).
+		Width(dialogWidth - 4).
+		Render("🔍 BUSCAR EN LA BIBLIOTECA")
+
+	inputText := m.libraryInput
+	if inputText == "" {
+		inputText = "Escribe tu búsqueda..."
+	}
+	inputBox := lipgloss.NewStyle().
+		Width(dialogWidth-6).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(royalBlueColor).
+		Padding(0, 1).
+		Foreground(brightWhiteColor).
+		Render(inputText)
+
+	parts := []string{title, "", inputBox}
+	if m.libraryStatus != "" {
+		parts = append(parts, lipgloss.NewStyle().
+			Foreground(lightGrayColor).
+			Width(dialogWidth-4).
+			Render(m.libraryStatus))
+	}
+
+	hintText := "Enter para buscar con las opciones de '/' | Esc para cerrar"
+	if len(m.libraryHits) > 0 {
+		// Only show a window of matches around the selection
+		viewStart, viewEnd := listWindow(m.currentLibraryIdx, len(m.libraryHits), m.libraryPageSize())
+
+		var items []string
+		for i := viewStart; i < viewEnd; i++ {
+			hit := m.libraryHits[i]
+			style := lipgloss.NewStyle().
+				Width(dialogWidth-8).
+				Padding(0, 1).
+				Align(lipgloss.Left)
+			if i == m.currentLibraryIdx {
+				style = style.
+					Background(darkGrayColor).
+					Foreground(brightWhiteColor)
+			} else {
+				style = style.Foreground(lightGrayColor)
+			}
+			location := hit.name
+			if hit.chapter != "" {
+				location += " — " + hit.chapter
+			}
+			location = fmt.Sprintf("%s — línea %d", location, hit.line+1)
+			header := lipgloss.NewStyle().Bold(true).MaxWidth(dialogWidth - 10).Render(location)
+			snippet := "  " + matchSnippet(hit.text, m.libraryMatcher, dialogWidth-12)
+			items = append(items, style.Render(header+"\n"+snippet))
+		}
+		parts = append(parts, lipgloss.NewStyle().
+			Width(dialogWidth-6).
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(royalBlueColor).
+			Padding(0, 1).
+			Render(lipgloss.JoinVertical(lipgloss.Left, items...)))
+		hintText = "j/k para navegar | PgUp/PgDn | Enter para abrir | Esc para cerrar"
+	}
+
+	hint := lipgloss.NewStyle().
+		Foreground(mediumGrayColor).
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(dialogWidth - 4).
+		Render(hintText)
+	parts = append(parts, "", hint)
+
+	dialog := lipgloss.NewStyle().
+		Width(dialogWidth).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(cyanColor).
+		Padding(1).
+		Background(greyColor).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
 
 	return dialog
 }
@@ -1103,6 +2427,33 @@ func (m UiModel) renderNoteDialog() string {
 	return dialog
 }
 
+type link struct {
+	name string
+	url  string // Format string receiving the escaped word
+}
+
+// dictionaries maps a document language to the dictionary used to look up words.
+var dictionaries = map[string]link{
+	"es": {name: "Real Academia Española", url: "https://dle.rae.es/%s"},
+	"en": {name: "Merriam-Webster", url: "https://www.merriam-webster.com/dictionary/%s"},
+	"fr": {name: "Larousse", url: "https://www.larousse.fr/dictionnaires/francais/%s"},
+	"it": {name: "Treccani", url: "https://www.treccani.it/vocabolario/ricerca/%s"},
+	"pt": {name: "Priberam", url: "https://dicionario.priberam.org/%s"},
+}
+
+// links returns the entries of the links dialog: the dictionary matching the
+// document language (RAE when unknown) followed by GoodReads.
+func (m UiModel) links() []link {
+	dictionary, ok := dictionaries[text.BaseLanguage(m.metadata.Language)]
+	if !ok {
+		dictionary = dictionaries["es"]
+	}
+	return []link{
+		dictionary,
+		{name: "GoodReads", url: "https://www.goodreads.com/search?q=%s"},
+	}
+}
+
 func (m UiModel) renderLinksDialog() string {
 	dialogWidth := 40
 
@@ -1113,9 +2464,8 @@ func (m UiModel) renderLinksDialog() string {
 		Width(dialogWidth - 4).
 		Render("Seleccionar Enlace")
 
-	links := []string{"Real Academia Española", "GoodReads"}
 	var linkItems []string
-	for i, link := range links {
+	for i, link := range m.links() {
 		style := lipgloss.NewStyle().
 			Width(dialogWidth-6).
 			Padding(0, 1).
@@ -1127,7 +2477,7 @@ func (m UiModel) renderLinksDialog() string {
 		} else {
 			style = style.Foreground(lightGrayColor)
 		}
-		linkItems = append(linkItems, style.Render(link))
+		linkItems = append(linkItems, style.Render(link.name))
 	}
 	linksList := lipgloss.JoinVertical(lipgloss.Left, linkItems...)
 
@@ -1264,8 +2614,10 @@ func (m UiModel) renderHelpDialog() string {
 				{"← / →", "Palabra anterior/siguiente"},
 				{"0", "Primera palabra de la línea"},
 				{"$", "Última palabra de la línea"},
-				{"g", "Ir a línea específica"},
+				{"g", "Ir a línea específica (o tiempo en subtítulos)"},
+				{"t", "Tabla de contenidos"},
 				{"PgUp/PgDn", "Página arriba/abajo"},
+				{"[ / ]", "Columna de texto más estrecha/ancha"},
 			},
 		},
 		{
@@ -1274,6 +2626,14 @@ func (m UiModel) renderHelpDialog() string {
 				{"/", "Abrir búsqueda"},
 				{"n", "Siguiente resultado"},
 				{"N (Shift+n)", "Resultado anterior"},
+				{"Ctrl+R", "Búsqueda por expresión regular (en el diálogo)"},
+				{"Ctrl+A", "Ignorar acentos (en el diálogo)"},
+				{"Ctrl+U", "Distinguir mayúsculas (en el diálogo)"},
+				{"Ctrl+W", "Solo palabras completas (en el diálogo)"},
+				{"Ctrl+F / Ctrl+D", "Búsqueda aproximada y su distancia (en el diálogo)"},
+				{"x", "Quitar el resaltado de la búsqueda"},
+				{"r", "Lista de resultados de la búsqueda"},
+				{"L (Shift+l)", "Buscar en todos los documentos leídos"},
 			},
 		},
 		{
@@ -1291,7 +2651,7 @@ func (m UiModel) renderHelpDialog() string {
 				{"w", "Agregar palabra al vocabulario"},
 				{"c", "Copiar palabra al portapapeles"},
 				{"n", "Crear nueva nota"},
-				{"o", "Abrir enlaces (RAE/GoodReads)"},
+				{"o", "Abrir enlaces (diccionario/GoodReads)"},
 				{"d", "Eliminar (vocabulario/nota)"},
 				{"s", "Guardar progreso"},
 			},
@@ -1370,20 +2730,57 @@ func browserOpenURLCommand(osName, url string) *exec.Cmd {
 	}
 }
 
-func (m *UiModel) performSearch(term string) []int {
+func (m *UiModel) performSearch(matcher *search.Matcher) []int {
 	var results []int
 	startLine := m.currentLine
 
+	// Fuzzy results are ranked, the lines closest to the term first. Equally
+	// close lines keep their order from the current line on.
+	if matcher.Options().Fuzzy {
+		type hit struct{ line, dist int }
+		var hits []hit
+		for j := range m.lines {
+			i := (startLine + j) % len(m.lines)
+			if dist, ok := matcher.Distance(m.lines[i]); ok {
+				hits = append(hits, hit{i, dist})
+			}
+		}
+		slices.SortStableFunc(hits, func(a, b hit) int { return a.dist - b.dist })
+		for _, h := range hits {
+			results = append(results, h.line)
+		}
+		return results
+	}
+
+	// Once the word index is ready only the lines holding every word of the
+	// term need to be checked.
+	if term, ok := matcher.Literal(); ok && m.wordIndex != nil {
+		if candidates, ok := m.wordIndex.Candidates(term); ok {
+			var wrapped []int
+			for _, i := range candidates {
+				if !matcher.Match(m.lines[i]) {
+					continue
+				}
+				if i < startLine {
+					wrapped = append(wrapped, i)
+				} else {
+					results = append(results, i)
+				}
+			}
+			return append(results, wrapped...)
+		}
+	}
+
 	// Buscar desde la línea actual hasta el final
 	for i := startLine; i < len(m.lines); i++ {
-		if strings.Contains(strings.ToLower(m.lines[i]), term) {
+		if matcher.Match(m.lines[i]) {
 			results = append(results, i)
 		}
 	}
 
 	// Buscar desde el inicio hasta la línea actual (búsqueda circular)
 	for i := 0; i < startLine; i++ {
-		if strings.Contains(strings.ToLower(m.lines[i]), term) {
+		if matcher.Match(m.lines[i]) {
 			results = append(results, i)
 		}
 	}
@@ -1391,6 +2788,205 @@ func (m *UiModel) performSearch(term string) []int {
 	return results
 }
 
+// currentMatch returns the match n and N are on.
+func (m UiModel) currentMatch() (search.Match, bool) {
+	if m.currentSearchIdx < 0 || m.currentSearchIdx >= len(m.searchResults) {
+		return search.Match{}, false
+	}
+	line := m.searchResults[m.currentSearchIdx]
+	matches := m.searchMatcher.FindAll(m.lines[line])
+	if m.currentMatchIdx < 0 || m.currentMatchIdx >= len(matches) {
+		return search.Match{Line: line}, false
+	}
+	return search.Match{Line: line, Start: matches[m.currentMatchIdx][0], End: matches[m.currentMatchIdx][1]}, true
+}
+
+// goToCurrentMatch moves the cursor to the word the current match starts in.
+func (m *UiModel) goToCurrentMatch() {
+	match, ok := m.currentMatch()
+	m.currentLine = match.Line
+	m.currentWordIdx = 0
+	if ok {
+		m.currentWordIdx = wordAt(m.lines[match.Line], match.Start)
+	}
+	m.syncViewportOffset()
+}
+
+// wordAt returns the index of the word of line at or after the given byte
+// offset.
+func wordAt(line string, offset int) int {
+	for i, word := range text.WordSpans(line) {
+		if word[1] > offset {
+			return i
+		}
+	}
+	return 0
+}
+
+// cursor is a reading position in the Texto tab, saved to go back to it.
+type cursor struct {
+	line, wordIdx   int
+	topLine, topRow int
+}
+
+func (m UiModel) cursor() cursor {
+	return cursor{line: m.currentLine, wordIdx: m.currentWordIdx, topLine: m.topLine, topRow: m.topRow}
+}
+
+func (m *UiModel) restoreCursor(c cursor) {
+	m.currentLine = c.line
+	m.currentWordIdx = c.wordIdx
+	m.topLine = c.topLine
+	m.topRow = c.topRow
+}
+
+// searchPreviewMsg delivers the first match of the term being typed in the
+// search dialog.
+type searchPreviewMsg struct {
+	id      int
+	line    int
+	wordIdx int
+	found   bool
+}
+
+// previewSearch looks for the first match of the term being typed in the
+// search dialog, counting from where the search started. Large documents take
+// a while to scan, so the scan runs in the background and the cursor moves
+// once it reports back; typing on cancels it.
+func (m *UiModel) previewSearch() tea.Cmd {
+	m.stopPreview()
+	m.searchPreview = nil
+	if m.searchInput == "" {
+		m.restoreCursor(m.searchOrigin)
+		return nil
+	}
+	// Regular expressions are often invalid halfway through typing them, the
+	// error is only shown on Enter.
+	matcher, err := search.New(m.searchInput, m.searchOptions)
+	if err != nil {
+		m.restoreCursor(m.searchOrigin)
+		return nil
+	}
+	m.searchPreview = matcher
+
+	var ctx context.Context
+	ctx, m.cancelPreview = context.WithCancel(context.Background())
+	id, lines, idx, start := m.searchPreviewID, m.lines, m.wordIndex, m.searchOrigin.line
+	return func() tea.Msg {
+		msg := searchPreviewMsg{id: id}
+		msg.line, msg.found = firstMatch(ctx, lines, idx, matcher, start)
+		if msg.found {
+			if matches := matcher.FindAll(lines[msg.line]); len(matches) > 0 {
+				msg.wordIdx = wordAt(lines[msg.line], matches[0][0])
+			}
+		}
+		return msg
+	}
+}
+
+// stopPreview cancels the preview scan still running, if any, and drops its
+// result.
+func (m *UiModel) stopPreview() {
+	m.searchPreviewID++
+	if m.cancelPreview != nil {
+		m.cancelPreview()
+		m.cancelPreview = nil
+	}
+}
+
+// firstMatch returns the first line of lines from start on, wrapping around
+// at the end, that matcher matches. It gives up once ctx is cancelled.
+func firstMatch(ctx context.Context, lines []string, idx *index.Index, matcher *search.Matcher, start int) (int, bool) {
+	if term, ok := matcher.Literal(); ok && idx != nil {
+		if candidates, ok := idx.Candidates(term); ok {
+			from, _ := slices.BinarySearch(candidates, start)
+			for j := range candidates {
+				if j%previewCheckEvery == 0 && ctx.Err() != nil {
+					return 0, false
+				}
+				if i := candidates[(from+j)%len(candidates)]; matcher.Match(lines[i]) {
+					return i, true
+				}
+			}
+			return 0, false
+		}
+	}
+	for j := range lines {
+		if j%previewCheckEvery == 0 && ctx.Err() != nil {
+			return 0, false
+		}
+		if i := (start + j) % len(lines); matcher.Match(lines[i]) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Lines scanned by firstMatch between checks for cancellation.
+const previewCheckEvery = 4096
+
+// lineMatches returns the ranges of line i to highlight as matches of the
+// last search, if any.
+func (m UiModel) lineMatches(i int) [][2]int {
+	if m.showSearchDialog && m.searchPreview != nil {
+		return m.searchPreview.FindAll(m.lines[i])
+	}
+	if !m.searchHighlight || m.searchMatcher == nil || len(m.searchResults) == 0 {
+		return nil
+	}
+	return m.searchMatcher.FindAll(m.lines[i])
+}
+
+// matchStyle returns style with the look of a search match, stronger for the
+// current one.
+func matchStyle(style lipgloss.Style, current bool) lipgloss.Style {
+	if current {
+		return style.
+			Bold(true).
+			Foreground(greyColor).
+			Background(currentMatchColor)
+	}
+	return style.
+		Foreground(brightWhiteColor).
+		Background(matchColor)
+}
+
+// fuzzyDistance returns the number of edits fuzzy searches with opts allow.
+func fuzzyDistance(opts search.Options) int {
+	if opts.MaxDistance > 0 {
+		return opts.MaxDistance
+	}
+	return search.DefaultMaxDistance
+}
+
+// describeSearch returns the term of a search as shown in the status bar,
+// between slashes if it is a regular expression and followed by the options
+// that were on.
+func describeSearch(matcher *search.Matcher) string {
+	opts := matcher.Options()
+	term := "'" + matcher.Term() + "'"
+	if opts.Regex {
+		term = "/" + matcher.Term() + "/"
+	}
+	var flags []string
+	if opts.IgnoreAccents {
+		flags = append(flags, "sin acentos")
+	}
+	if opts.CaseSensitive {
+		flags = append(flags, "mayúsculas")
+	}
+	if opts.WholeWord {
+		flags = append(flags, "palabra completa")
+	}
+	if opts.Fuzzy {
+		flags = append(flags, fmt.Sprintf("aproximada, distancia %d", fuzzyDistance(opts)))
+	}
+	if len(flags) > 0 {
+		term += " (" + strings.Join(flags, ", ") + ")"
+	}
+	return term
+}
+
 func (m UiModel) renderSearchDialog() string {
 	dialogWidth := utils.Min(m.width*2/3, 60)
 
@@ -1417,6 +3013,26 @@ func (m UiModel) renderSearchDialog() string {
 		Foreground(brightWhiteColor).
 		Render(inputText)
 
+	// Search options, each toggled with its own key
+	check := func(on bool) string {
+		if on {
+			return "[x]"
+		}
+		return "[ ]"
+	}
+	opts := m.searchOptions
+	modeLine := lipgloss.NewStyle().
+		Foreground(lightGrayColor).
+		Width(dialogWidth - 4).
+		Render(strings.Join([]string{
+			fmt.Sprintf("%s %s Expresión regular", keyToggleRegex, check(opts.Regex)),
+			fmt.Sprintf("%s %s Ignorar acentos", keyToggleAccents, check(opts.IgnoreAccents)),
+			fmt.Sprintf("%s %s Distinguir mayúsculas", keyToggleCase, check(opts.CaseSensitive)),
+			fmt.Sprintf("%s %s Palabra completa", keyToggleWholeWord, check(opts.WholeWord)),
+			fmt.Sprintf("%s %s Aproximada, hasta %d cambios (%s)",
+				keyToggleFuzzy, check(opts.Fuzzy), fuzzyDistance(opts), keyFuzzyDistance),
+		}, "\n"))
+
 	// Buttons
 	searchButton := lipgloss.NewStyle().
 		Foreground(brightWhiteColor).
@@ -1440,9 +3056,17 @@ func (m UiModel) renderSearchDialog() string {
 		Italic(true).
 		Align(lipgloss.Center).
 		Width(dialogWidth - 4).
-		Render(fmt.Sprintf("Usa '%s' para siguiente resultado, '%s' para anterior", keyNextSearch, keyPrevSearch))
-
-	dialogContent := lipgloss.JoinVertical(lipgloss.Left, title, "", inputBox, "", buttons, "", hint)
+		Render(fmt.Sprintf("Usa '%s' para siguiente resultado, '%s' para anterior, ↑/↓ para el historial", keyNextSearch, keyPrevSearch))
+
+	parts := []string{title, "", inputBox, modeLine}
+	if m.searchError != "" {
+		parts = append(parts, lipgloss.NewStyle().
+			Foreground(redColor).
+			Width(dialogWidth-4).
+			Render("Patrón no válido: "+m.searchError))
+	}
+	parts = append(parts, "", buttons, "", hint)
+	dialogContent := lipgloss.JoinVertical(lipgloss.Left, parts...)
 
 	dialog := lipgloss.NewStyle().
 		Width(dialogWidth).
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openTestModel writes text to a file named name in dir and opens it like
+// the reader does, with its statistics counted and a terminal size.
+func openTestModel(t *testing.T, dir, name, text string, opts Options) UiModel {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := InitialModel(path, opts)
+	if err != nil {
+		t.Fatalf("InitialModel(%q): %v", path, err)
+	}
+	m = run(t, m, m.Init())
+	return update(t, m, tea.WindowSizeMsg{Width: 100, Height: 24})
+}
+
+// update passes msg to m, running the command it returns.
+func update(t *testing.T, m UiModel, msg tea.Msg) UiModel {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return run(t, next.(UiModel), cmd)
+}
+
+// run runs cmd, and the commands of a batch, passing the messages they
+// return to m.
+func run(t *testing.T, m UiModel, cmd tea.Cmd) UiModel {
+	t.Helper()
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case nil:
+		return m
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			m = run(t, m, cmd)
+		}
+		return m
+	default:
+		return update(t, m, msg)
+	}
+}
+
+// press types keys, given by name like "enter" or as the text typed.
+func press(t *testing.T, m UiModel, keys ...string) UiModel {
+	t.Helper()
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		}
+		m = update(t, m, msg)
+	}
+	return m
+}
+
+func TestSearchHighlight(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := openTestModel(t, t.TempDir(), "cats.txt", "one cat\ndog\ncat and cat\n", Options{})
+
+	m = press(t, m, keySearch, "c", "a", "t", "enter")
+	if !slices.Equal(m.searchResults, []int{0, 2}) {
+		t.Fatalf("searchResults = %v, want [0 2]", m.searchResults)
+	}
+	if got := m.lineMatches(2); !slices.Equal(got, [][2]int{{0, 3}, {8, 11}}) {
+		t.Errorf("lineMatches(2) = %v, want [[0 3] [8 11]]", got)
+	}
+	if got := m.lineMatches(1); got != nil {
+		t.Errorf("lineMatches(1) = %v, want none", got)
+	}
+
+	m = press(t, m, keyNextSearch, keyNextSearch)
+	if m.currentLine != 2 || m.currentMatchIdx != 1 {
+		t.Errorf("after two %q: line %d, match %d, want line 2, match 1", keyNextSearch, m.currentLine, m.currentMatchIdx)
+	}
+	m = press(t, m, keyPrevSearch, keyPrevSearch)
+	if m.currentLine != 0 || m.currentMatchIdx != 0 {
+		t.Errorf("after two %q: line %d, match %d, want line 0, match 0", keyPrevSearch, m.currentLine, m.currentMatchIdx)
+	}
+
+	m = press(t, m, keyClearSearch)
+	if got := m.lineMatches(2); got != nil {
+		t.Errorf("lineMatches(2) = %v after clearing the search, want none", got)
+	}
+}
+
+func TestSearchPreview(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := openTestModel(t, t.TempDir(), "animals.txt", "cat\ndog\nbird\n", Options{})
+
+	m = press(t, m, keySearch, "b", "i")
+	if m.currentLine != 2 {
+		t.Errorf("previewing %q: line %d, want 2", m.searchInput, m.currentLine)
+	}
+	m = press(t, m, "x")
+	if m.currentLine != 0 {
+		t.Errorf("previewing %q: line %d, want 0 where the search started", m.searchInput, m.currentLine)
+	}
+
+	// The result of a term no longer being typed is dropped.
+	m = press(t, m, "backspace", "backspace")
+	stale := m.previewSearch()
+	m = press(t, m, "d")
+	m = update(t, m, stale())
+	if m.currentLine != 0 {
+		t.Errorf("stale preview moved the cursor to line %d", m.currentLine)
+	}
+
+	m = press(t, m, "esc")
+	if m.showSearchDialog || m.currentLine != 0 {
+		t.Errorf("after esc: dialog shown %v, line %d, want closed at line 0", m.showSearchDialog, m.currentLine)
+	}
+}
+
+func TestResultsDialog(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := openTestModel(t, t.TempDir(), "cats.txt", "cat\ndog\ncat\ndog\ncat\n", Options{})
+
+	m = press(t, m, keySearch, "c", "a", "t", "enter", keySearchResults)
+	if !m.showResultsDialog || m.currentResultIdx != 0 {
+		t.Fatalf("results dialog shown %v at %d, want shown at 0", m.showResultsDialog, m.currentResultIdx)
+	}
+	m = press(t, m, "down", "down", "down")
+	if m.currentResultIdx != 2 {
+		t.Errorf("currentResultIdx = %d, want the last result, 2", m.currentResultIdx)
+	}
+	m = press(t, m, "enter")
+	if m.showResultsDialog || m.currentLine != 4 {
+		t.Errorf("after enter: dialog shown %v, line %d, want closed at line 4", m.showResultsDialog, m.currentLine)
+	}
+}
+
+func TestListWindow(t *testing.T) {
+	tests := []struct {
+		selected, total, size int
+		start, end            int
+	}{
+		{0, 3, 5, 0, 3},
+		{0, 100, 5, 0, 5},
+		{10, 100, 5, 8, 13},
+		{99, 100, 5, 95, 100},
+	}
+	for _, tt := range tests {
+		if start, end := listWindow(tt.selected, tt.total, tt.size); start != tt.start || end != tt.end {
+			t.Errorf("listWindow(%d, %d, %d) = %d, %d, want %d, %d", tt.selected, tt.total, tt.size, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestLibrarySearch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	// Read before in Latin-1, which is remembered for it.
+	other := openTestModel(t, dir, "other.txt", "x\nneedle se\xf1or\n", Options{Encoding: "latin1"})
+	press(t, other, keySave)
+
+	m := openTestModel(t, dir, "current.txt", "needle\nhay\n", Options{})
+	docs, err := m.libraryDocs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []libraryDoc{{path: filepath.Join(dir, "current.txt")}, {path: filepath.Join(dir, "other.txt"), encoding: "latin1"}}
+	if !slices.Equal(docs, want) {
+		t.Errorf("libraryDocs = %+v, want %+v", docs, want)
+	}
+
+	m = press(t, m, keyLibrarySearch, "n", "e", "e", "d", "l", "e", "enter")
+	if len(m.libraryHits) != 2 {
+		t.Fatalf("libraryHits = %+v, want 2 hits", m.libraryHits)
+	}
+	if hit := m.libraryHits[1]; hit.line != 1 || hit.text != "needle señor" {
+		t.Errorf("hit in other.txt = line %d %q, want line 1 %q", hit.line, hit.text, "needle señor")
+	}
+
+	m = press(t, m, "down", "enter")
+	if m.filePath != filepath.Join(dir, "other.txt") || m.currentLine != 1 {
+		t.Fatalf("opened %s at line %d, want other.txt at line 1", m.filePath, m.currentLine)
+	}
+	if m.lines[1] != "needle señor" {
+		t.Errorf("line 1 = %q, want it read as Latin-1", m.lines[1])
+	}
+}
@@ -10,6 +10,11 @@ func HashPath(path string) string {
 	return hex.EncodeToString(h[:])
 }
 
+func HashContent(data []byte) string {
+	h := md5.Sum(data)
+	return hex.EncodeToString(h[:])
+}
+
 func Max[T ~int | ~float64](a, b T) T {
 	if a > b {
 		return a
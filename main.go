@@ -4,24 +4,58 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"txtreader/internal/document"
 	"txtreader/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	fileFlag := flag.String("file", "", "Text file to open")
+	fileFlag := flag.String("file", "", "Text file to open, or - to read from standard input")
+	encodingFlag := flag.String("encoding", "", "Character encoding of the file (e.g. utf-8, latin1, windows-1252); detected when empty (remembered per book)")
+	gutenbergFlag := flag.Bool("gutenberg", false, "Strip Project Gutenberg header/license and rejoin hard-wrapped lines (remembered per book)")
 	flag.Parse()
 
-	m, err := ui.InitialModel(*fileFlag)
+	// Read piped input when no file is given, e.g. "man ls | txtreader".
+	filePath := *fileFlag
+	if filePath == "" && !isTerminal(os.Stdin) {
+		filePath = document.StdinPath
+	}
+	if filePath == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	opts := ui.Options{Encoding: *encodingFlag}
+	flag.Visit(func(f *flag.Flag) {
+		// Only override the remembered setting when given explicitly.
+		if f.Name == "gutenberg" {
+			opts.Gutenberg = gutenbergFlag
+		}
+	})
+
+	m, err := ui.InitialModel(filePath, opts)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithMouseAllMotion())
+	programOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithMouseAllMotion()}
+	if filePath == document.StdinPath {
+		// Stdin was consumed by the document, so keyboard input comes from the TTY.
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(m, programOpts...)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("fatal: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}